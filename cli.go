@@ -0,0 +1,447 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aereal/migrate-gh-repo/config"
+	"github.com/aereal/migrate-gh-repo/domain"
+	"github.com/aereal/migrate-gh-repo/external"
+	"github.com/aereal/migrate-gh-repo/gitmirror"
+	"github.com/aereal/migrate-gh-repo/journal"
+	"github.com/aereal/migrate-gh-repo/logging"
+	"github.com/aereal/migrate-gh-repo/mapping"
+	"github.com/aereal/migrate-gh-repo/usecase"
+)
+
+var errUsage = errors.New("usage error")
+
+type command struct {
+	name        string
+	description string
+	run         func(ctx context.Context, args []string) error
+}
+
+var commands = []*command{
+	{name: "plan", description: "print requests to be sent to the target repository without executing them", run: runPlan},
+	{name: "apply", description: "migrate the source repository into the target repository", run: runApply},
+	{name: "verify", description: "check the configuration and access to both repositories", run: runVerify},
+	{name: "export", description: "dump the source repository or the mapping of migrated entities", run: runExport},
+}
+
+func run(argv []string) error {
+	if len(argv) < 2 {
+		usage(os.Stderr)
+		return errUsage
+	}
+	name := argv[1]
+	if name == "-h" || name == "-help" || name == "--help" || name == "help" {
+		usage(os.Stdout)
+		return nil
+	}
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		return cmd.run(ctx, argv[2:])
+	}
+	fmt.Fprintf(os.Stderr, "unknown command: %q\n", name)
+	usage(os.Stderr)
+	return errUsage
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: migrate-gh-repo <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.description)
+	}
+	fmt.Fprintf(w, "\nRun `migrate-gh-repo <command> -h` to see flags of each command.\n")
+}
+
+type globalOptions struct {
+	configPath     string
+	mappingPath    string
+	logLevel       string
+	retryAttempts  int
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+}
+
+func (o *globalOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.configPath, "config", "./config/default.cue", "path to the configuration file")
+	fs.StringVar(&o.mappingPath, "mapping", "", "path to the file to record source-to-target identities of migrated entities")
+	fs.StringVar(&o.logLevel, "log-level", "info", "log level (debug, info, warn or error)")
+	fs.IntVar(&o.retryAttempts, "retry-attempts", 5, "max attempts of each API request failed transiently (e.g. 502)")
+	fs.DurationVar(&o.retryBaseDelay, "retry-base-delay", time.Second, "delay before the first retry; doubled on each retry")
+	fs.DurationVar(&o.retryMaxDelay, "retry-max-delay", time.Minute, "upper bound of delay between retries")
+}
+
+type app struct {
+	cfg      *config.Config
+	usecase  *usecase.Usecase
+	mappings *mapping.Store
+}
+
+func (a *app) Close() error {
+	if a.mappings == nil {
+		return nil
+	}
+	return a.mappings.Close()
+}
+
+func (o *globalOptions) setup(ctx context.Context, opts ...usecase.Option) (*app, error) {
+	level, err := logging.ParseLevel(o.logLevel)
+	if err != nil {
+		return nil, err
+	}
+	logging.SetLevel(level)
+	if o.retryAttempts < 1 {
+		return nil, fmt.Errorf("-retry-attempts must be positive: %d", o.retryAttempts)
+	}
+	opts = append(opts, usecase.WithRetryPolicy(external.NewRetryPolicy(o.retryAttempts, o.retryBaseDelay, o.retryMaxDelay)))
+
+	cfg, err := config.Load(o.configPath)
+	if err != nil {
+		return nil, err
+	}
+	logging.Debugf("config = %#v", cfg)
+
+	if err := cfg.Source.CheckReachability(ctx); err != nil {
+		return nil, fmt.Errorf("source endpoint: %w", err)
+	}
+	if err := cfg.Target.CheckReachability(ctx); err != nil {
+		return nil, fmt.Errorf("target endpoint: %w", err)
+	}
+
+	sourceClient, err := cfg.Source.GitHubClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	targetClient, err := cfg.Target.GitHubClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, usecase.WithWebURLs(cfg.Source.WebURL(), cfg.Target.WebURL()))
+	opts = append(opts, usecase.WithIssueNumberPreservation(cfg.PreserveIssueNumbers))
+	opts = append(opts, usecase.WithMentionNeutralization(cfg.Mentions.Neutralize))
+	opts = append(opts, usecase.WithPullRequestRecreation(cfg.PullRequests.Recreate))
+	opts = append(opts, usecase.WithReleases(cfg.Releases.Migrate))
+	opts = append(opts, usecase.WithSourceDownloadClient(cfg.Source.DownloadClient()))
+	opts = append(opts, usecase.WithLabelConflictPolicy(domain.ConflictPolicy(cfg.Labels.OnConflict)))
+	opts = append(opts, usecase.WithLabelMapping(domain.NewLabelMapper(cfg.LabelMapping)))
+	opts = append(opts, usecase.WithPruning(cfg.Labels.Prune, cfg.Milestones.Prune))
+	if cfg.IssueBody.Copy {
+		header, err := domain.NewAttributionTemplate(cfg.IssueBody.Header)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, usecase.WithCopiedIssueBody(header))
+	}
+
+	if cfg.Comments.Migrate {
+		header, err := domain.NewAttributionTemplate(cfg.Comments.Header)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, usecase.WithIssueComments(header))
+		opts = append(opts, usecase.WithPullRequestReviews(cfg.Comments.Reviews))
+	}
+
+	var mappings *mapping.Store
+	if o.mappingPath != "" {
+		mappings, err = mapping.Open(o.mappingPath)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, usecase.WithMappingStore(mappings))
+	}
+
+	resolver := domain.NewUserAliasResolver(cfg.UserAliases)
+	u, err := usecase.New(resolver, sourceClient, targetClient, cfg.SkipUsers, opts...)
+	if err != nil {
+		if mappings != nil {
+			mappings.Close()
+		}
+		return nil, err
+	}
+	return &app{cfg: cfg, usecase: u, mappings: mappings}, nil
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "unexpected arguments: %v\n", fs.Args())
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+func runPlan(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	opts := &globalOptions{}
+	opts.register(fs)
+	var format, out string
+	fs.StringVar(&format, "format", "table", "output format (table or json)")
+	fs.StringVar(&out, "out", "", "path to save the plan to apply later (.json or .cue)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if format != "table" && format != "json" {
+		fmt.Fprintf(fs.Output(), "unknown format: %q\n", format)
+		return errUsage
+	}
+
+	a, err := opts.setup(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	plan, err := a.usecase.Plan(ctx, a.cfg.Source.Repo, a.cfg.Target.Repo)
+	if err != nil {
+		return err
+	}
+	if out != "" {
+		if err := plan.Save(out); err != nil {
+			return err
+		}
+		logging.Infof("plan saved to %s", out)
+	}
+	if format == "json" {
+		return plan.WriteJSON(os.Stdout)
+	}
+	return plan.WriteTable(os.Stdout)
+}
+
+func runApply(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	opts := &globalOptions{}
+	opts.register(fs)
+	var planPath, journalPath, reportPath string
+	var continueOnError bool
+	fs.StringVar(&planPath, "plan", "", "path to the plan saved by `plan -out`; the plan is computed if not given")
+	fs.StringVar(&journalPath, "journal", "", "path to the journal file to record completed requests and resume from")
+	fs.BoolVar(&continueOnError, "continue-on-error", false, "keep going after a request failed and report all failures at the end")
+	fs.StringVar(&reportPath, "report", "", "path to write the failure report as JSON (with -continue-on-error)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	usecaseOpts := []usecase.Option{usecase.WithContinueOnError(continueOnError)}
+	if journalPath != "" {
+		j, err := journal.Open(journalPath)
+		if err != nil {
+			return err
+		}
+		defer j.Close()
+		if n := j.Len(); n > 0 {
+			logging.Infof("resume from journal %s: %d requests already completed", journalPath, n)
+		}
+		usecaseOpts = append(usecaseOpts, usecase.WithJournal(j))
+	}
+
+	a, err := opts.setup(ctx, usecaseOpts...)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	if a.cfg.Git.Mirror {
+		if err := newGitMirror(a.cfg).Run(ctx); err != nil {
+			return fmt.Errorf("failed to mirror git repository: %w", err)
+		}
+	}
+	if planPath == "" {
+		err = a.usecase.Migrate(ctx, a.cfg.Source.Repo, a.cfg.Target.Repo)
+	} else {
+		var plan *usecase.Plan
+		plan, err = usecase.LoadPlan(planPath)
+		if err != nil {
+			return err
+		}
+		if target := fmt.Sprintf("%s/%s", a.cfg.Target.Repo.Owner, a.cfg.Target.Repo.Name); plan.Target != target {
+			return fmt.Errorf("plan (%q) is made for %s but the target is %s", planPath, plan.Target, target)
+		}
+		err = a.usecase.Apply(ctx, plan)
+	}
+	// the wiki is migrated unless the migration stopped at a failure
+	var report *usecase.FailureReport
+	errors.As(err, &report)
+	if a.cfg.Wiki.Migrate {
+		if err != nil && report == nil {
+			logging.Warnf("skip migrating wiki since the migration failed")
+		} else if werr := migrateWiki(ctx, a); werr != nil {
+			if !continueOnError {
+				return fmt.Errorf("failed to migrate wiki: %w", werr)
+			}
+			logging.Errorf("failed to migrate wiki: %v", werr)
+			if report == nil {
+				// every request succeeded
+				report = a.usecase.LastReport()
+				err = report
+			}
+			report.AddFailure("migrate_wiki", fmt.Sprintf("%s/%s.wiki", a.cfg.Target.Repo.Owner, a.cfg.Target.Repo.Name), werr)
+		}
+	}
+
+	if report != nil {
+		if werr := report.WriteTable(os.Stderr); werr != nil {
+			return werr
+		}
+		if reportPath != "" {
+			if werr := writeOutput(reportPath, report.WriteJSON); werr != nil {
+				return werr
+			}
+			logging.Infof("failure report written to %s", reportPath)
+		}
+	}
+	return err
+}
+
+func newGitMirror(cfg *config.Config) *gitmirror.Mirror {
+	m := &gitmirror.Mirror{
+		Source:   &gitmirror.Remote{URL: cfg.Git.SourceURL, Token: cfg.Source.Token},
+		Target:   &gitmirror.Remote{URL: cfg.Git.TargetURL, Token: cfg.Target.Token},
+		PullRefs: cfg.Git.PullRefs,
+	}
+	if m.Source.URL == "" {
+		m.Source.URL = cfg.Source.CloneURL()
+	}
+	if m.Target.URL == "" {
+		m.Target.URL = cfg.Target.CloneURL()
+	}
+	return m
+}
+
+func migrateWiki(ctx context.Context, a *app) error {
+	w := &gitmirror.Wiki{
+		Source: &gitmirror.Remote{URL: a.cfg.Wiki.SourceURL, Token: a.cfg.Source.Token},
+		Target: &gitmirror.Remote{URL: a.cfg.Wiki.TargetURL, Token: a.cfg.Target.Token},
+	}
+	if w.Source.URL == "" {
+		w.Source.URL = a.cfg.Source.WikiCloneURL()
+	}
+	if w.Target.URL == "" {
+		w.Target.URL = a.cfg.Target.WikiCloneURL()
+	}
+	if a.cfg.Wiki.RewriteLinks {
+		rewrite, err := a.usecase.WikiLinkRewriter(ctx, a.cfg.Source.Repo, a.cfg.Target.Repo)
+		if err != nil {
+			return err
+		}
+		w.Rewrite = rewrite
+	}
+	return w.Run(ctx)
+}
+
+func runVerify(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	opts := &globalOptions{}
+	opts.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	a, err := opts.setup(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	if err := a.usecase.Verify(ctx, a.cfg.Source.Repo, a.cfg.Target.Repo); err != nil {
+		return err
+	}
+	fmt.Printf("ok: %s/%s -> %s/%s\n", a.cfg.Source.Repo.Owner, a.cfg.Source.Repo.Name, a.cfg.Target.Repo.Owner, a.cfg.Target.Repo.Name)
+	return nil
+}
+
+func runExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	opts := &globalOptions{}
+	opts.register(fs)
+	var kind, format, out string
+	fs.StringVar(&kind, "kind", "source", "what to export (source or mapping); mapping requires -mapping")
+	fs.StringVar(&format, "format", "json", "output format of the mapping (json or csv)")
+	fs.StringVar(&out, "out", "-", "path to write the exported data (- means stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	switch kind {
+	case "source":
+		if format != "json" {
+			fmt.Fprintf(fs.Output(), "source can be exported only as json: %q\n", format)
+			return errUsage
+		}
+	case "mapping":
+		if format != "json" && format != "csv" {
+			fmt.Fprintf(fs.Output(), "unknown format: %q\n", format)
+			return errUsage
+		}
+		if opts.mappingPath == "" {
+			fmt.Fprintf(fs.Output(), "-mapping must be given to export the mapping\n")
+			return errUsage
+		}
+		return exportMapping(opts.mappingPath, format, out)
+	default:
+		fmt.Fprintf(fs.Output(), "unknown kind: %q\n", kind)
+		return errUsage
+	}
+
+	a, err := opts.setup(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	snapshot, err := a.usecase.Export(ctx, a.cfg.Source.Repo)
+	if err != nil {
+		return err
+	}
+	return writeOutput(out, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(snapshot)
+	})
+}
+
+func exportMapping(path, format, out string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("cannot read mapping: %w", err)
+	}
+	store, err := mapping.Open(path)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	if format == "csv" {
+		return writeOutput(out, store.WriteCSV)
+	}
+	return writeOutput(out, store.WriteJSON)
+}
+
+func writeOutput(path string, write func(w io.Writer) error) error {
+	if path == "-" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -5,8 +5,10 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"cuelang.org/go/cue"
+	"github.com/aereal/migrate-gh-repo/external"
 	"github.com/google/go-github/github"
 	"golang.org/x/oauth2"
 )
@@ -18,33 +20,173 @@ type Repository struct {
 
 type Endpoint struct {
 	URL                   string `json:"url"`
+	UploadURL             string `json:"uploadURL"`
 	Token                 string `json:"token"`
 	IgnoreSSLVerification bool
 	Repo                  *Repository
 }
 
-func (e *Endpoint) GitHubClient(ctx context.Context) (*github.Client, error) {
+func (e *Endpoint) httpClient(ctx context.Context) *http.Client {
 	// TODO: disable ssl verification
 	httpClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{
 		AccessToken: e.Token,
 	}))
-	httpClient.Transport.(*oauth2.Transport).Base = &http.Transport{
+	httpClient.Transport.(*oauth2.Transport).Base = external.NewRateLimitTransport(e.transport())
+	return httpClient
+}
+
+func (e *Endpoint) transport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: e.IgnoreSSLVerification,
 		},
 	}
+}
+
+// DownloadClient returns the client without credentials of the API to fetch signed URLs redirected from the endpoint (e.g. release assets).
+func (e *Endpoint) DownloadClient() *http.Client {
+	return &http.Client{Transport: e.transport()}
+}
 
+func (e *Endpoint) GitHubClient(ctx context.Context) (*github.Client, error) {
+	httpClient := e.httpClient(ctx)
 	if e.URL != "" {
-		return github.NewEnterpriseClient(e.URL, e.URL /* TODO */, httpClient)
+		uploadURL, err := e.uploadBaseURL()
+		if err != nil {
+			return nil, err
+		}
+		return github.NewEnterpriseClient(e.URL, uploadURL, httpClient)
 	}
 	return github.NewClient(httpClient), nil
 }
 
+// uploadBaseURL returns the base URL to upload release assets.
+// GitHub Enterprise serves uploads under /api/uploads/ instead of /api/v3/ unless uploadURL is given.
+func (e *Endpoint) uploadBaseURL() (string, error) {
+	if e.UploadURL != "" {
+		return e.UploadURL, nil
+	}
+	if e.URL == "" {
+		return "https://uploads.github.com/", nil
+	}
+	base := strings.TrimSuffix(e.URL, "/")
+	if strings.HasSuffix(base, "/api/v3") {
+		return strings.TrimSuffix(base, "/api/v3") + "/api/uploads/", nil
+	}
+	return "", fmt.Errorf("cannot tell where to upload release assets from url (%q) not ending with /api/v3; set uploadURL explicitly", e.URL)
+}
+
+// CheckReachability tells whether both of the API and the upload endpoints respond.
+// Any response is accepted except server errors, since endpoints may refuse requests without specific paths.
+func (e *Endpoint) CheckReachability(ctx context.Context) error {
+	apiURL := e.URL
+	if apiURL == "" {
+		apiURL = "https://api.github.com/"
+	}
+	uploadURL, err := e.uploadBaseURL()
+	if err != nil {
+		return err
+	}
+	httpClient := e.httpClient(ctx)
+	for _, u := range []string{apiURL, uploadURL} {
+		req, err := http.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return fmt.Errorf("invalid endpoint URL (%q): %w", u, err)
+		}
+		resp, err := httpClient.Do(req.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("cannot reach %s: %w", u, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("cannot reach %s: %s", u, resp.Status)
+		}
+	}
+	return nil
+}
+
+// WebURL returns the base URL of web pages served by the endpoint.
+func (e *Endpoint) WebURL() string {
+	if e.URL == "" {
+		return "https://github.com"
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(e.URL, "/"), "/api/v3")
+}
+
+// CloneURL returns the URL to clone the repository over HTTPS.
+func (e *Endpoint) CloneURL() string {
+	return fmt.Sprintf("%s/%s/%s.git", e.WebURL(), e.Repo.Owner, e.Repo.Name)
+}
+
+// WikiCloneURL returns the URL to clone the wiki of the repository over HTTPS.
+func (e *Endpoint) WikiCloneURL() string {
+	return fmt.Sprintf("%s/%s/%s.wiki.git", e.WebURL(), e.Repo.Owner, e.Repo.Name)
+}
+
+type IssueBody struct {
+	Copy   bool   `json:"copy"`
+	Header string `json:"header"`
+}
+
+type Comments struct {
+	Migrate bool   `json:"migrate"`
+	Header  string `json:"header"`
+	Reviews bool   `json:"reviews"`
+}
+
+type Mentions struct {
+	Neutralize bool `json:"neutralize"`
+}
+
+type PullRequests struct {
+	Recreate bool `json:"recreate"`
+}
+
+type Labels struct {
+	OnConflict string `json:"onConflict"`
+	Prune      bool   `json:"prune"`
+}
+
+type Milestones struct {
+	Prune bool `json:"prune"`
+}
+
+type Releases struct {
+	Migrate bool `json:"migrate"`
+}
+
+type Git struct {
+	Mirror    bool   `json:"mirror"`
+	PullRefs  bool   `json:"pullRefs"`
+	SourceURL string `json:"sourceURL"`
+	TargetURL string `json:"targetURL"`
+}
+
+type Wiki struct {
+	Migrate      bool   `json:"migrate"`
+	RewriteLinks bool   `json:"rewriteLinks"`
+	SourceURL    string `json:"sourceURL"`
+	TargetURL    string `json:"targetURL"`
+}
+
 type Config struct {
-	Source      Endpoint          `json:"source"`
-	Target      Endpoint          `json:"target"`
-	UserAliases map[string]string `json:"userAliases"`
-	SkipUsers   []string          `json:"skipUsers"`
+	Source       Endpoint          `json:"source"`
+	Target       Endpoint          `json:"target"`
+	UserAliases  map[string]string `json:"userAliases"`
+	LabelMapping map[string]string `json:"labelMapping"`
+	SkipUsers    []string          `json:"skipUsers"`
+	IssueBody    IssueBody         `json:"issueBody"`
+	Comments     Comments          `json:"comments"`
+	Mentions     Mentions          `json:"mentions"`
+	Labels       Labels            `json:"labels"`
+	Milestones   Milestones        `json:"milestones"`
+	PullRequests PullRequests      `json:"pullRequests"`
+	Git          Git               `json:"git"`
+	Releases     Releases          `json:"releases"`
+	Wiki         Wiki              `json:"wiki"`
+
+	PreserveIssueNumbers bool `json:"preserveIssueNumbers"`
 }
 
 func Load(configFilePath string) (*Config, error) {
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEndpoint_uploadBaseURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint *Endpoint
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "github.com",
+			endpoint: &Endpoint{},
+			want:     "https://uploads.github.com/",
+		},
+		{
+			name:     "GitHub Enterprise",
+			endpoint: &Endpoint{URL: "https://ghe.example.com/api/v3/"},
+			want:     "https://ghe.example.com/api/uploads/",
+		},
+		{
+			name:     "GitHub Enterprise without trailing slash",
+			endpoint: &Endpoint{URL: "https://ghe.example.com/api/v3"},
+			want:     "https://ghe.example.com/api/uploads/",
+		},
+		{
+			name:     "explicit",
+			endpoint: &Endpoint{URL: "https://ghe.example.com/api/v3/", UploadURL: "https://uploads.ghe.example.com/"},
+			want:     "https://uploads.ghe.example.com/",
+		},
+		{
+			name:     "unknown layout",
+			endpoint: &Endpoint{URL: "http://127.0.0.1:8080/"},
+			wantErr:  true,
+		},
+		{
+			name:     "unknown layout with explicit upload URL",
+			endpoint: &Endpoint{URL: "http://127.0.0.1:8080/", UploadURL: "http://127.0.0.1:8080/uploads/"},
+			want:     "http://127.0.0.1:8080/uploads/",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.endpoint.uploadBaseURL()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("uploadBaseURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("uploadBaseURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_defaults(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "default.cue")
+	content := `
+source: {
+	token: "x"
+	repo: {
+		fullName: "aereal/src"
+	}
+}
+target: {
+	token: "y"
+	repo: {
+		fullName: "aereal/dest"
+	}
+}
+`
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the spec is loaded relative to the root of the repository
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(".."); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.PreserveIssueNumbers {
+		t.Error("PreserveIssueNumbers must be opt-in not to fail migrations into repositories with issues")
+	}
+	if cfg.Source.Repo.Owner != "aereal" || cfg.Target.Repo.Name != "dest" {
+		t.Errorf("repositories = %+v, %+v", cfg.Source.Repo, cfg.Target.Repo)
+	}
+}
+
+func TestEndpoint_DownloadClient(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("asset"))
+	}))
+	defer srv.Close()
+
+	if _, err := (&Endpoint{Token: "x"}).DownloadClient().Get(srv.URL); err == nil {
+		t.Error("expected error for the self-signed certificate")
+	}
+	resp, err := (&Endpoint{Token: "x", IgnoreSSLVerification: true}).DownloadClient().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+}
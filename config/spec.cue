@@ -6,6 +6,11 @@ UserAliases :: {
 	<from>: !=""
 }
 
+// source label name to target label name; labels mapped onto the same name are merged
+LabelMapping :: {
+	<from>: !=""
+}
+
 Repository :: {
 	fullName: !=""
 	parts:    strings.Split(fullName, "/")
@@ -15,12 +20,89 @@ Repository :: {
 
 Endpoint :: {
 	url?:                   string
+	// base URL to upload release assets; derived from url if omitted (e.g. https://ghe.example.com/api/uploads/ for https://ghe.example.com/api/v3/)
+	uploadURL?:             string
 	token:                  string & !=""
 	ignoreSSLVerification?: bool | *false
 	repo:                   Repository
 }
 
+IssueBody :: {
+	// copy the original body instead of putting only a link to the source issue
+	copy: bool | *false
+	// text/template prepended to the copied body; .Author, .CreatedAt and .URL are available
+	header: string | *"_Originally created by **{{.Author}}** at {{.CreatedAt}} in {{.URL}}_"
+}
+
+Comments :: {
+	// migrate comments on issues
+	migrate: bool | *false
+	// text/template prepended to each comment; .Author, .CreatedAt and .URL are available
+	header: string | *"_Originally commented by **{{.Author}}** at {{.CreatedAt}} in {{.URL}}_"
+	// migrate reviews and review comments on pull requests as well
+	reviews: bool | *true
+}
+
+Mentions :: {
+	// wrap @-mentions in copied bodies and comments in backticks not to notify users during the migration
+	neutralize: bool | *false
+}
+
+Labels :: {
+	// which color and description win when a label exists on both repositories with different ones; "source" overwrites the target
+	onConflict: *"source" | "target"
+	// delete labels only on the target (e.g. default labels) to mirror the source
+	prune: bool | *false
+}
+
+Milestones :: {
+	// delete milestones only on the target to mirror the source
+	prune: bool | *false
+}
+
+PullRequests :: {
+	// create open pull requests as pull requests if both of head and base branches exist on the target; otherwise as issues
+	recreate: bool | *false
+}
+
+Git :: {
+	// mirror branches and tags of the git repository before migrating issues and others on apply
+	mirror: bool | *false
+	// push heads of pull requests (refs/pull/N/head) as pull/N branches as well
+	pullRefs: bool | *false
+	// clone URLs or local paths; derived from url and repo of endpoints if omitted
+	sourceURL?: string
+	targetURL?: string
+}
+
+Releases :: {
+	// recreate releases with the same tags and upload their assets again
+	migrate: bool | *false
+}
+
+Wiki :: {
+	// copy pages of the wiki after migrating issues and others on apply; the target wiki must have the first page created on the web
+	migrate: bool | *false
+	// rewrite links to the source repository and its issues in markdown pages
+	rewriteLinks: bool | *true
+	// clone URLs or local paths; derived from url and repo of endpoints if omitted
+	sourceURL?: string
+	targetURL?: string
+}
+
 source: Endpoint
 target: Endpoint
 userAliases: UserAliases
+labelMapping: LabelMapping
 skipUsers: [...string]
+issueBody: IssueBody
+comments: Comments
+mentions: Mentions
+labels: Labels
+milestones: Milestones
+pullRequests: PullRequests
+git: Git
+releases: Releases
+wiki: Wiki
+// create issues with the same numbers as the source, filling numbers of deleted or transferred issues with placeholder issues; the target must have no issues or pull requests numbered at or above the first one to be created
+preserveIssueNumbers: bool | *false
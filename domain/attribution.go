@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+)
+
+// Attribution describes where and by whom the migrated content was originally written.
+type Attribution struct {
+	Author    string
+	CreatedAt string
+	URL       string
+}
+
+func NewAttribution(author string, createdAt time.Time, url string) *Attribution {
+	a := &Attribution{Author: author, URL: url}
+	if !createdAt.IsZero() {
+		a.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+	}
+	return a
+}
+
+type AttributionTemplate struct {
+	tmpl *template.Template
+}
+
+func NewAttributionTemplate(text string) (*AttributionTemplate, error) {
+	tmpl, err := template.New("attribution").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse attribution template: %w", err)
+	}
+	return &AttributionTemplate{tmpl: tmpl}, nil
+}
+
+// Render prepends the attribution header to the body.
+func (t *AttributionTemplate) Render(a *Attribution, body string) (string, error) {
+	buf := &bytes.Buffer{}
+	if err := t.tmpl.Execute(buf, a); err != nil {
+		return "", fmt.Errorf("failed to render attribution: %w", err)
+	}
+	if body != "" {
+		buf.WriteString("\n\n")
+		buf.WriteString(body)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAttributionTemplate_Render(t *testing.T) {
+	type args struct {
+		attribution *Attribution
+		body        string
+	}
+	tests := []struct {
+		name     string
+		template string
+		args     args
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "with body",
+			template: "Originally created by {{.Author}} at {{.CreatedAt}} in {{.URL}}",
+			args: args{
+				attribution: NewAttribution("aereal", time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC), "https://github.com/aereal/src/issues/1"),
+				body:        "poppoe",
+			},
+			want: "Originally created by aereal at 2019-10-01T12:00:00Z in https://github.com/aereal/src/issues/1\n\npoppoe",
+		},
+		{
+			name:     "empty body",
+			template: "by {{.Author}}",
+			args: args{
+				attribution: NewAttribution("aereal", time.Time{}, ""),
+				body:        "",
+			},
+			want: "by aereal",
+		},
+		{
+			name:     "unknown field",
+			template: "by {{.Nobody}}",
+			args: args{
+				attribution: NewAttribution("aereal", time.Time{}, ""),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := NewAttributionTemplate(tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tmpl.Render(tt.args.attribution, tt.args.body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AttributionTemplate.Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("AttributionTemplate.Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
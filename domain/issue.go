@@ -8,6 +8,49 @@ import (
 	"github.com/google/go-github/github"
 )
 
+const markerKindIssue = "issue"
+
+// EmbedIssueMarker appends the marker pointing the source issue by its node ID to the body of the migrated issue.
+// The body is kept as it is if the node ID is unknown.
+func EmbedIssueMarker(body string, source *github.Issue) string {
+	if source.GetNodeID() == "" {
+		return body
+	}
+	return EmbedMarker(body, markerKindIssue, source.GetNodeID())
+}
+
+// IssueMarkerSourceID returns the node ID of the source issue which the target issue was migrated from.
+func IssueMarkerSourceID(target *github.Issue) (nodeID string, ok bool) {
+	return ExtractMarker(target.GetBody(), markerKindIssue)
+}
+
+// MatchIssues finds the target issue of each source issue, keyed by source issue numbers.
+// Issues are matched by markers first so that edits and diverged numbers do not break the identity;
+// target issues without markers, e.g. ones created before the migration, are matched by numbers.
+func MatchIssues(sourceIssues, targetIssues []*github.Issue) map[int]*github.Issue {
+	byNodeID := map[string]*github.Issue{}
+	byNumber := map[int]*github.Issue{}
+	for _, t := range targetIssues {
+		if id, ok := IssueMarkerSourceID(t); ok {
+			byNodeID[id] = t
+			continue
+		}
+		byNumber[t.GetNumber()] = t
+	}
+
+	matched := map[int]*github.Issue{}
+	for _, s := range sourceIssues {
+		if t, ok := byNodeID[s.GetNodeID()]; ok && s.GetNodeID() != "" {
+			matched[s.GetNumber()] = t
+			continue
+		}
+		if t, ok := byNumber[s.GetNumber()]; ok {
+			matched[s.GetNumber()] = t
+		}
+	}
+	return matched
+}
+
 type issue struct {
 	*github.Issue
 	normalizedAssignees string
@@ -19,7 +62,7 @@ func (i *issue) hasMigrated() bool {
 		return false
 	}
 	for _, label := range i.Labels {
-		if label.GetName() == "migrated" {
+		if label.GetName() == MigratedLabel {
 			return true
 		}
 	}
@@ -77,23 +120,38 @@ func NewIssueOpsList(sourceIssues, targetIssues []*github.Issue) IssueOpsList {
 		return nil
 	}
 
+	matched := MatchIssues(sourceIssues, targetIssues)
 	kinds := opMapping{}
 	for _, s := range sourceIssues {
 		src := &issue{
 			Issue: s,
 		}
 		kinds.requestCreate(src)
-		for _, t := range targetIssues {
-			target := &issue{
-				Issue: t,
-			}
-			if src.Key().Eq(target.Key()) {
-				if target.hasMigrated() || src.eq(target) { // completely equal
-					kinds.requestNothing(src)
-				} else {
-					kinds.requestUpdate(src)
-				}
+		t, ok := matched[s.GetNumber()]
+		if !ok {
+			continue
+		}
+		target := &issue{
+			Issue: t,
+		}
+		if _, migrated := IssueMarkerSourceID(t); migrated {
+			// only the state is synced since the rest may have been edited on the target
+			if s.GetState() != t.GetState() {
+				kinds.requestUpdate(src)
+			} else {
+				kinds.requestNothing(src)
 			}
+			continue
+		}
+		if target.hasMigrated() {
+			kinds.requestNothing(src)
+			continue
+		}
+		// matched by the number
+		if src.eq(target) { // completely equal
+			kinds.requestNothing(src)
+		} else {
+			kinds.requestUpdate(src)
 		}
 	}
 
@@ -110,8 +168,9 @@ func NewIssueOpsList(sourceIssues, targetIssues []*github.Issue) IssueOpsList {
 			})
 		case OpUpdate:
 			ops = append(ops, &IssueOp{
-				Kind:  OpUpdate,
-				Issue: s,
+				Kind:        OpUpdate,
+				Issue:       s,
+				TargetIssue: matched[s.GetNumber()],
 			})
 		default:
 		}
@@ -131,8 +190,16 @@ func (il IssueOpsList) String() string {
 }
 
 type IssueOp struct {
-	Kind  OpKind
-	Issue *github.Issue
+	Kind        OpKind
+	Issue       *github.Issue
+	TargetIssue *github.Issue // nil unless Kind is OpUpdate
+}
+
+// Migrated tells whether the target issue of the update has been created by the migration, whose state should be synced.
+// Otherwise the target issue existing before the migration is linked to the source one.
+func (op *IssueOp) Migrated() bool {
+	_, ok := IssueMarkerSourceID(op.TargetIssue)
+	return ok
 }
 
 func (op *IssueOp) String() string {
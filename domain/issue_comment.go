@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/google/go-github/github"
+)
+
+const markerKindComment = "comment"
+
+type issueComment struct {
+	*github.IssueComment
+	sourceID string
+}
+
+func newSourceIssueComment(c *github.IssueComment) *issueComment {
+	return &issueComment{IssueComment: c, sourceID: strconv.FormatInt(c.GetID(), 10)}
+}
+
+func newTargetIssueComment(c *github.IssueComment) *issueComment {
+	id, _ := CommentMarkerSourceID(c)
+	return &issueComment{IssueComment: c, sourceID: id}
+}
+
+func (c *issueComment) Key() *Key {
+	if c == nil || c.sourceID == "" {
+		return nil
+	}
+	return &Key{kind: "issue_comment", repr: c.sourceID}
+}
+
+// EmbedCommentMarker marks the body of the comment to be created from the source comment.
+func EmbedCommentMarker(body string, source *github.IssueComment) string {
+	return EmbedMarker(body, markerKindComment, strconv.FormatInt(source.GetID(), 10))
+}
+
+// CommentMarkerSourceID returns the ID of the source comment the target comment migrated from.
+func CommentMarkerSourceID(target *github.IssueComment) (string, bool) {
+	return ExtractMarker(target.GetBody(), markerKindComment)
+}
+
+// NewIssueCommentOpsList tells source comments not migrated yet to the target issue.
+// Target comments are matched by the marker embedded by EmbedCommentMarker.
+func NewIssueCommentOpsList(sourceComments, targetComments []*github.IssueComment) IssueCommentOpsList {
+	if len(sourceComments) == 0 && len(targetComments) == 0 {
+		return nil
+	}
+
+	kinds := opMapping{}
+	for _, s := range sourceComments {
+		src := newSourceIssueComment(s)
+		kinds.requestCreate(src)
+		for _, t := range targetComments {
+			target := newTargetIssueComment(t)
+			if src.Key().Eq(target.Key()) {
+				kinds.requestNothing(src)
+			}
+		}
+	}
+
+	ops := []*IssueCommentOp{}
+	for _, s := range sourceComments {
+		src := newSourceIssueComment(s)
+		switch kinds.get(src) {
+		case OpCreate:
+			ops = append(ops, &IssueCommentOp{
+				Kind:         OpCreate,
+				IssueComment: s,
+			})
+		default:
+		}
+	}
+	return ops
+}
+
+type IssueCommentOpsList []*IssueCommentOp
+
+func (l IssueCommentOpsList) String() string {
+	s := "["
+	for _, op := range l {
+		s += fmt.Sprintf("%s, ", op)
+	}
+	s += "]"
+	return s
+}
+
+type IssueCommentOp struct {
+	Kind         OpKind
+	IssueComment *github.IssueComment
+}
+
+func (op *IssueCommentOp) String() string {
+	return stringify(op.Kind, op.IssueComment)
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/github"
+)
+
+func int64Ref(i int64) *int64 { return &i }
+
+func TestNewIssueCommentOpsList(t *testing.T) {
+	type args struct {
+		sourceComments []*github.IssueComment
+		targetComments []*github.IssueComment
+	}
+	tests := []struct {
+		name string
+		args args
+		want IssueCommentOpsList
+	}{
+		{
+			name: "empty",
+			args: args{
+				sourceComments: []*github.IssueComment{},
+				targetComments: []*github.IssueComment{},
+			},
+			want: nil,
+		},
+		{
+			name: "source=[A,B] target=[A(migrated), human]",
+			args: args{
+				sourceComments: []*github.IssueComment{
+					&github.IssueComment{ID: int64Ref(1), Body: strRef("first")},
+					&github.IssueComment{ID: int64Ref(2), Body: strRef("second")},
+				},
+				targetComments: []*github.IssueComment{
+					&github.IssueComment{ID: int64Ref(100), Body: strRef(EmbedCommentMarker("first", &github.IssueComment{ID: int64Ref(1)}))},
+					&github.IssueComment{ID: int64Ref(101), Body: strRef("written on target")},
+				},
+			},
+			want: IssueCommentOpsList{
+				&IssueCommentOp{
+					Kind:         OpCreate,
+					IssueComment: &github.IssueComment{ID: int64Ref(2), Body: strRef("second")},
+				},
+			},
+		},
+		{
+			name: "all migrated",
+			args: args{
+				sourceComments: []*github.IssueComment{
+					&github.IssueComment{ID: int64Ref(1), Body: strRef("first")},
+				},
+				targetComments: []*github.IssueComment{
+					&github.IssueComment{ID: int64Ref(100), Body: strRef(EmbedCommentMarker("edited on target", &github.IssueComment{ID: int64Ref(1)}))},
+				},
+			},
+			want: IssueCommentOpsList{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewIssueCommentOpsList(tt.args.sourceComments, tt.args.targetComments); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewIssueCommentOpsList() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
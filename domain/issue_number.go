@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/go-github/github"
+)
+
+// IssueNumberGaps returns numbers to be filled by placeholder issues so that issues created by ops get the same numbers as on the source.
+// The target repository numbers issues and pull requests sequentially, so it fails if an issue to be created has a number already taken on the target.
+func IssueNumberGaps(targetIssues []*github.Issue, ops IssueOpsList) ([]int, error) {
+	highest := 0
+	for _, t := range targetIssues {
+		if t.GetNumber() > highest {
+			highest = t.GetNumber()
+		}
+	}
+
+	toCreate := []int{}
+	for _, op := range ops {
+		if op.Kind == OpCreate {
+			toCreate = append(toCreate, op.Issue.GetNumber())
+		}
+	}
+	if len(toCreate) == 0 {
+		return nil, nil
+	}
+	if !sort.IntsAreSorted(toCreate) {
+		return nil, fmt.Errorf("issues to be created must be ordered by number to preserve numbers")
+	}
+	if toCreate[0] <= highest {
+		return nil, fmt.Errorf("cannot preserve issue number #%d: target repository already has issues or pull requests up to #%d", toCreate[0], highest)
+	}
+
+	gaps := []int{}
+	next := highest + 1
+	for _, num := range toCreate {
+		for ; next < num; next++ {
+			gaps = append(gaps, next)
+		}
+		next = num + 1
+	}
+	return gaps, nil
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/github"
+)
+
+func TestIssueNumberGaps(t *testing.T) {
+	createOp := func(num int) *IssueOp {
+		return &IssueOp{Kind: OpCreate, Issue: &github.Issue{Number: intRef(num)}}
+	}
+	type args struct {
+		targetIssues []*github.Issue
+		ops          IssueOpsList
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []int
+		wantErr bool
+	}{
+		{
+			name: "nothing to create",
+			args: args{
+				targetIssues: []*github.Issue{},
+				ops:          IssueOpsList{},
+			},
+			want: nil,
+		},
+		{
+			name: "no gaps",
+			args: args{
+				targetIssues: []*github.Issue{},
+				ops:          IssueOpsList{createOp(1), createOp(2)},
+			},
+			want: []int{},
+		},
+		{
+			name: "deleted issues on source",
+			args: args{
+				targetIssues: []*github.Issue{},
+				ops:          IssueOpsList{createOp(2), createOp(3), createOp(6)},
+			},
+			want: []int{1, 4, 5},
+		},
+		{
+			name: "resume after some issues created",
+			args: args{
+				targetIssues: []*github.Issue{&github.Issue{Number: intRef(1)}, &github.Issue{Number: intRef(2)}},
+				ops: IssueOpsList{
+					&IssueOp{Kind: OpUpdate, Issue: &github.Issue{Number: intRef(2)}},
+					createOp(4),
+				},
+			},
+			want: []int{3},
+		},
+		{
+			name: "number already taken",
+			args: args{
+				targetIssues: []*github.Issue{&github.Issue{Number: intRef(1)}, &github.Issue{Number: intRef(3)}},
+				ops:          IssueOpsList{createOp(2)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unordered",
+			args: args{
+				targetIssues: []*github.Issue{},
+				ops:          IssueOpsList{createOp(2), createOp(1)},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IssueNumberGaps(tt.args.targetIssues, tt.args.ops)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("IssueNumberGaps() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IssueNumberGaps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -114,6 +114,106 @@ func TestNewIssueOpsList(t *testing.T) {
 			},
 			want: IssueOpsList([]*IssueOp{}),
 		},
+		{
+			name: "source=[A] target=[A'] with different assignees",
+			args: args{
+				sourceIssues: []*github.Issue{
+					&github.Issue{
+						Number:    intRef(1),
+						Title:     strRef("poppoe"),
+						Assignees: []*github.User{{Login: strRef("aereal")}},
+					},
+				},
+				targetIssues: []*github.Issue{
+					&github.Issue{
+						Number: intRef(1),
+						Title:  strRef("poppoe"),
+					},
+				},
+			},
+			want: IssueOpsList([]*IssueOp{
+				&IssueOp{
+					Kind: OpUpdate,
+					Issue: &github.Issue{
+						Number:    intRef(1),
+						Title:     strRef("poppoe"),
+						Assignees: []*github.User{{Login: strRef("aereal")}},
+					},
+					TargetIssue: &github.Issue{
+						Number: intRef(1),
+						Title:  strRef("poppoe"),
+					},
+				},
+			}),
+		},
+		{
+			name: "source=[A,B] target=[B migrated as #1, A edited]",
+			args: args{
+				sourceIssues: []*github.Issue{
+					&github.Issue{
+						Number: intRef(1),
+						NodeID: strRef("MDU6SXNzdWUx"),
+						Title:  strRef("poppoe1"),
+					},
+					&github.Issue{
+						Number: intRef(2),
+						NodeID: strRef("MDU6SXNzdWUy"),
+						Title:  strRef("poppoe2"),
+					},
+				},
+				targetIssues: []*github.Issue{
+					&github.Issue{
+						Number: intRef(1),
+						Title:  strRef("poppoe2"),
+						Body:   strRef("<!-- migrate-gh-repo:issue=MDU6SXNzdWUy -->"),
+					},
+					&github.Issue{
+						Number: intRef(2),
+						Title:  strRef("poppoe1 (edited)"),
+						Body:   strRef("<!-- migrate-gh-repo:issue=MDU6SXNzdWUx -->"),
+					},
+				},
+			},
+			want: IssueOpsList([]*IssueOp{}),
+		},
+		{
+			name: "source=[A closed] target=[A migrated but left open]",
+			args: args{
+				sourceIssues: []*github.Issue{
+					&github.Issue{
+						Number: intRef(1),
+						NodeID: strRef("MDU6SXNzdWUx"),
+						Title:  strRef("poppoe1"),
+						State:  strRef("closed"),
+					},
+				},
+				targetIssues: []*github.Issue{
+					&github.Issue{
+						Number: intRef(3),
+						Title:  strRef("poppoe1"),
+						State:  strRef("open"),
+						Body:   strRef("<!-- migrate-gh-repo:issue=MDU6SXNzdWUx -->"),
+					},
+				},
+			},
+			want: IssueOpsList([]*IssueOp{
+				&IssueOp{
+					Kind: OpUpdate,
+					Issue: &github.Issue{
+						Number: intRef(1),
+						NodeID: strRef("MDU6SXNzdWUx"),
+						Title:  strRef("poppoe1"),
+						State:  strRef("closed"),
+					},
+					TargetIssue: &github.Issue{
+						Number: intRef(3),
+						Title:  strRef("poppoe1"),
+						State:  strRef("open"),
+						Body:   strRef("<!-- migrate-gh-repo:issue=MDU6SXNzdWUx -->"),
+					},
+				},
+			}),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -123,3 +223,31 @@ func TestNewIssueOpsList(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchIssues(t *testing.T) {
+	migrated := &github.Issue{Number: intRef(5), Body: strRef(EmbedIssueMarker("body", &github.Issue{NodeID: strRef("MDU6SXNzdWUx")}))}
+	preexisting := &github.Issue{Number: intRef(2)}
+	other := &github.Issue{Number: intRef(3), Body: strRef(EmbedIssueMarker("", &github.Issue{NodeID: strRef("MDU6SXNzdWU5")}))}
+	sourceIssues := []*github.Issue{
+		{Number: intRef(1), NodeID: strRef("MDU6SXNzdWUx")},
+		{Number: intRef(2), NodeID: strRef("MDU6SXNzdWUy")},
+		{Number: intRef(3), NodeID: strRef("MDU6SXNzdWUz")},
+	}
+
+	got := MatchIssues(sourceIssues, []*github.Issue{migrated, preexisting, other})
+	want := map[int]*github.Issue{1: migrated, 2: preexisting}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MatchIssues() = %v, want %v", got, want)
+	}
+}
+
+func TestIssueOp_Migrated(t *testing.T) {
+	migrated := &IssueOp{Kind: OpUpdate, TargetIssue: &github.Issue{Body: strRef(EmbedIssueMarker("body", &github.Issue{NodeID: strRef("MDU6SXNzdWUx")}))}}
+	if !migrated.Migrated() {
+		t.Error("Migrated() must be true for the target issue with the marker")
+	}
+	preexisting := &IssueOp{Kind: OpUpdate, TargetIssue: &github.Issue{Body: strRef("body")}}
+	if preexisting.Migrated() {
+		t.Error("Migrated() must be false for the target issue without the marker")
+	}
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/google/go-github/github"
 )
@@ -24,7 +25,7 @@ func (l *label) eq(other *label) bool {
 	if !l.Key().Eq(other.Key()) {
 		return false
 	}
-	return l.GetColor() == other.GetColor() && l.GetDescription() == other.GetDescription()
+	return strings.EqualFold(l.GetColor(), other.GetColor()) && l.GetDescription() == other.GetDescription()
 }
 
 type LabelOpsList []*LabelOp
@@ -47,7 +48,8 @@ func (op *LabelOp) String() string {
 	return stringify(op.Kind, op.Label)
 }
 
-func NewLabelOpsList(sourceLabels, targetLabels []*github.Label) LabelOpsList {
+// NewLabelOpsList tells labels to be created, and ones to be updated if their colors or descriptions differ and the policy is SourceWins.
+func NewLabelOpsList(sourceLabels, targetLabels []*github.Label, policy ConflictPolicy) LabelOpsList {
 	if len(sourceLabels) == 0 && len(targetLabels) == 0 {
 		return nil
 	}
@@ -59,10 +61,10 @@ func NewLabelOpsList(sourceLabels, targetLabels []*github.Label) LabelOpsList {
 		for _, tgt := range targetLabels {
 			tgtm := &label{tgt}
 			if srcm.Key().Eq(tgtm.Key()) {
-				if srcm.eq(tgtm) { // completely equal
+				if srcm.eq(tgtm) || policy == TargetWins { // completely equal or drift kept
 					kinds.requestNothing(srcm)
 				} else {
-					kinds.requestNothing(srcm)
+					kinds.requestUpdate(srcm)
 				}
 			}
 		}
@@ -87,3 +89,28 @@ func NewLabelOpsList(sourceLabels, targetLabels []*github.Label) LabelOpsList {
 	}
 	return LabelOpsList(ops)
 }
+
+// MigratedLabel is put on target issues referring to the source ones, which tells they have been migrated.
+const MigratedLabel = "migrated"
+
+// NewLabelPruneOpsList tells labels only on the target to be deleted.
+// Labels used by the migration itself are never deleted.
+func NewLabelPruneOpsList(sourceLabels, targetLabels []*github.Label) LabelOpsList {
+	inSource := map[string]bool{
+		(&label{&github.Label{Name: github.String(MigratedLabel)}}).Key().String(): true,
+	}
+	for _, src := range sourceLabels {
+		inSource[(&label{src}).Key().String()] = true
+	}
+	ops := []*LabelOp{}
+	for _, tgt := range targetLabels {
+		if inSource[(&label{tgt}).Key().String()] {
+			continue
+		}
+		ops = append(ops, &LabelOp{
+			Kind:  OpDelete,
+			Label: tgt,
+		})
+	}
+	return LabelOpsList(ops)
+}
@@ -0,0 +1,52 @@
+package domain
+
+import "github.com/google/go-github/github"
+
+// LabelMapper translates names of labels on the source repository into ones on the target.
+// Several source labels mapped onto the same name are merged into one label.
+type LabelMapper struct {
+	mapping map[string]string
+}
+
+func NewLabelMapper(mapping map[string]string) *LabelMapper {
+	return &LabelMapper{mapping: mapping}
+}
+
+func (m *LabelMapper) Map(name string) string {
+	if mapped, ok := m.mapping[name]; ok {
+		return mapped
+	}
+	return name
+}
+
+// MapNames returns names on the target of labels put on an issue without duplicates, keeping the order.
+func (m *LabelMapper) MapNames(labels []github.Label) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, l := range labels {
+		name := m.Map(l.GetName())
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// MapLabels returns labels renamed for the target; merged labels take the color and the description of the first one.
+func (m *LabelMapper) MapLabels(labels []*github.Label) []*github.Label {
+	mapped := []*github.Label{}
+	seen := map[string]bool{}
+	for _, l := range labels {
+		name := m.Map(l.GetName())
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		renamed := *l
+		renamed.Name = &name
+		mapped = append(mapped, &renamed)
+	}
+	return mapped
+}
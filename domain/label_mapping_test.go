@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/github"
+)
+
+func TestLabelMapper(t *testing.T) {
+	mapper := NewLabelMapper(map[string]string{
+		"bug":    "type: bug",
+		"defect": "type: bug",
+	})
+	labels := []*github.Label{
+		{Name: strRef("defect"), Color: strRef("ff0000")},
+		{Name: strRef("question"), Color: strRef("0000ff")},
+		{Name: strRef("bug"), Color: strRef("00ff00")},
+	}
+
+	issueLabels := []github.Label{*labels[0], *labels[1], *labels[2]}
+	if got, want := mapper.MapNames(issueLabels), []string{"type: bug", "question"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MapNames() = %v, want %v", got, want)
+	}
+	wantLabels := []*github.Label{
+		{Name: strRef("type: bug"), Color: strRef("ff0000")},
+		{Name: strRef("question"), Color: strRef("0000ff")},
+	}
+	if got := mapper.MapLabels(labels); !reflect.DeepEqual(got, wantLabels) {
+		t.Errorf("MapLabels() = %v, want %v", got, wantLabels)
+	}
+	if got := labels[0].GetName(); got != "defect" {
+		t.Errorf("source label renamed: %q", got)
+	}
+}
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/github"
+)
+
+func TestNewLabelOpsList(t *testing.T) {
+	type args struct {
+		sourceLabels []*github.Label
+		targetLabels []*github.Label
+		policy       ConflictPolicy
+	}
+	tests := []struct {
+		name string
+		args args
+		want LabelOpsList
+	}{
+		{
+			name: "source=empty target=empty",
+			args: args{policy: SourceWins},
+			want: nil,
+		},
+		{
+			name: "source=[A,B] target=[A]",
+			args: args{
+				sourceLabels: []*github.Label{
+					{Name: strRef("bug"), Color: strRef("ff0000")},
+					{Name: strRef("feature"), Color: strRef("00ff00")},
+				},
+				targetLabels: []*github.Label{
+					{Name: strRef("bug"), Color: strRef("FF0000")},
+				},
+				policy: SourceWins,
+			},
+			want: LabelOpsList{
+				{Kind: OpCreate, Label: &github.Label{Name: strRef("feature"), Color: strRef("00ff00")}},
+			},
+		},
+		{
+			name: "source=[A] target=[A'] source wins",
+			args: args{
+				sourceLabels: []*github.Label{
+					{Name: strRef("bug"), Color: strRef("ff0000"), Description: strRef("something is broken")},
+				},
+				targetLabels: []*github.Label{
+					{Name: strRef("bug"), Color: strRef("0000ff")},
+				},
+				policy: SourceWins,
+			},
+			want: LabelOpsList{
+				{Kind: OpUpdate, Label: &github.Label{Name: strRef("bug"), Color: strRef("ff0000"), Description: strRef("something is broken")}},
+			},
+		},
+		{
+			name: "source=[A] target=[A'] target wins",
+			args: args{
+				sourceLabels: []*github.Label{
+					{Name: strRef("bug"), Color: strRef("ff0000"), Description: strRef("something is broken")},
+				},
+				targetLabels: []*github.Label{
+					{Name: strRef("bug"), Color: strRef("0000ff")},
+				},
+				policy: TargetWins,
+			},
+			want: LabelOpsList{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewLabelOpsList(tt.args.sourceLabels, tt.args.targetLabels, tt.args.policy); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewLabelOpsList() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewLabelPruneOpsList(t *testing.T) {
+	sourceLabels := []*github.Label{{Name: strRef("bug")}}
+	targetLabels := []*github.Label{{Name: strRef("bug")}, {Name: strRef("good first issue")}, {Name: strRef("migrated")}}
+	want := LabelOpsList{
+		{Kind: OpDelete, Label: &github.Label{Name: strRef("good first issue")}},
+	}
+	if got := NewLabelPruneOpsList(sourceLabels, targetLabels); !reflect.DeepEqual(got, want) {
+		t.Errorf("NewLabelPruneOpsList() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+)
+
+var inlineCodePattern = regexp.MustCompile("`[^`\n]*`")
+
+// mapOutsideCode applies f to texts of the Markdown body except for code blocks and code spans,
+// where GitHub renders references and mentions literally.
+func mapOutsideCode(body string, f func(text string) string) string {
+	var out, text strings.Builder
+	flush := func() {
+		out.WriteString(mapOutsideCodeSpans(text.String(), f))
+		text.Reset()
+	}
+	fence := ""
+	for _, line := range strings.SplitAfter(body, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		switch {
+		case fence != "":
+			out.WriteString(line)
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			fence = trimmed[:3]
+			out.WriteString(line)
+		default:
+			text.WriteString(line)
+		}
+	}
+	flush()
+	return out.String()
+}
+
+func mapOutsideCodeSpans(text string, f func(text string) string) string {
+	var out strings.Builder
+	last := 0
+	for _, loc := range inlineCodePattern.FindAllStringIndex(text, -1) {
+		out.WriteString(f(text[last:loc[0]]))
+		out.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	out.WriteString(f(text[last:]))
+	return out.String()
+}
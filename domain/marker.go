@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Markers are hidden HTML comments embedded into migrated contents to tell which source entity they came from.
+
+const markerPrefix = "migrate-gh-repo"
+
+var markerPattern = regexp.MustCompile(`<!-- ` + markerPrefix + `:([a-z_]+)=(\S+) -->`)
+
+func EmbedMarker(body, kind, id string) string {
+	marker := fmt.Sprintf("<!-- %s:%s=%s -->", markerPrefix, kind, id)
+	if body == "" {
+		return marker
+	}
+	return body + "\n\n" + marker
+}
+
+func ExtractMarker(body, kind string) (id string, ok bool) {
+	for _, m := range markerPattern.FindAllStringSubmatch(body, -1) {
+		if m[1] == kind {
+			return m[2], true
+		}
+	}
+	return "", false
+}
+
+// LastMarker returns the marker embedded at last in the body, i.e. the one pointing the source of the migrated content.
+func LastMarker(body string) (string, bool) {
+	markers := markerPattern.FindAllString(body, -1)
+	if len(markers) == 0 {
+		return "", false
+	}
+	return markers[len(markers)-1], true
+}
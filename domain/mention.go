@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// e.g. @aereal, @org/team; not e-mail addresses such as aereal@example.com
+var mentionPattern = regexp.MustCompile(`(^|[^\w@/.-])@([A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?)(/[A-Za-z0-9_.-]+)?\b`)
+
+// MentionRewriter translates @-mentions in migrated bodies to users on the target with the alias resolver.
+// If neutralize is true mentions are wrapped in backticks not to notify users.
+type MentionRewriter struct {
+	resolver   *UserAliasResolver
+	neutralize bool
+}
+
+func NewMentionRewriter(resolver *UserAliasResolver, neutralize bool) *MentionRewriter {
+	return &MentionRewriter{resolver: resolver, neutralize: neutralize}
+}
+
+func (r *MentionRewriter) Rewrite(body string) string {
+	return mapOutsideCode(body, func(text string) string {
+		return mentionPattern.ReplaceAllStringFunc(text, r.rewrite)
+	})
+}
+
+func (r *MentionRewriter) rewrite(s string) string {
+	m := mentionPattern.FindStringSubmatch(s)
+	prefix, login, team := m[1], m[2], m[3]
+	// teams belong to the organization, not to users
+	if team == "" {
+		login, _ = r.resolver.AssumeResolved(login)
+	}
+	mention := fmt.Sprintf("@%s%s", login, team)
+	if r.neutralize {
+		mention = "`" + mention + "`"
+	}
+	return prefix + mention
+}
@@ -0,0 +1,42 @@
+package domain
+
+import "testing"
+
+func TestMentionRewriter_Rewrite(t *testing.T) {
+	resolver := NewUserAliasResolver(map[string]string{"aereal": "noreal"})
+	tests := []struct {
+		name       string
+		neutralize bool
+		body       string
+		want       string
+	}{
+		{
+			name: "aliased",
+			body: "cc @aereal, @other",
+			want: "cc @noreal, @other",
+		},
+		{
+			name: "team",
+			body: "cc @aereal/team",
+			want: "cc @aereal/team",
+		},
+		{
+			name: "not mentions",
+			body: "aereal@example.com @@aereal `@aereal`\n```\n@aereal\n```",
+			want: "aereal@example.com @@aereal `@aereal`\n```\n@aereal\n```",
+		},
+		{
+			name:       "neutralized",
+			neutralize: true,
+			body:       "@aereal: see @org/team",
+			want:       "`@noreal`: see `@org/team`",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewMentionRewriter(resolver, tt.neutralize).Rewrite(tt.body); got != tt.want {
+				t.Errorf("Rewrite() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/google/go-github/github"
 )
@@ -68,6 +69,28 @@ func NewMilestoneOpsList(sourceMilestones, targetMilestones []*github.Milestone)
 	return MilestoneOpsList(ops)
 }
 
+// MilestonesToClose tells closed source milestones whose counterparts are missing or still open on the target.
+// Milestones are created open to assign issues, so ones left open by an interrupted run are closed again on re-runs.
+func MilestonesToClose(sourceMilestones, targetMilestones []*github.Milestone) []*github.Milestone {
+	toClose := []*github.Milestone{}
+	for _, src := range sourceMilestones {
+		if src.GetState() != "closed" {
+			continue
+		}
+		srcm := &milestone{src}
+		closed := false
+		for _, tgt := range targetMilestones {
+			if srcm.Key().Eq((&milestone{tgt}).Key()) && tgt.GetState() == "closed" {
+				closed = true
+			}
+		}
+		if !closed {
+			toClose = append(toClose, src)
+		}
+	}
+	return toClose
+}
+
 type MilestoneOpsList []*MilestoneOp
 
 func (l MilestoneOpsList) String() string {
@@ -87,3 +110,39 @@ type MilestoneOp struct {
 func (op *MilestoneOp) String() string {
 	return stringify(op.Kind, op.Milestone)
 }
+
+// NewMilestonePruneOpsList tells milestones only on the target to be deleted.
+func NewMilestonePruneOpsList(sourceMilestones, targetMilestones []*github.Milestone) MilestoneOpsList {
+	inSource := map[string]bool{}
+	for _, src := range sourceMilestones {
+		inSource[(&milestone{src}).Key().String()] = true
+	}
+	ops := []*MilestoneOp{}
+	for _, tgt := range targetMilestones {
+		if inSource[(&milestone{tgt}).Key().String()] {
+			continue
+		}
+		ops = append(ops, &MilestoneOp{
+			Kind:      OpDelete,
+			Milestone: tgt,
+		})
+	}
+	return MilestoneOpsList(ops)
+}
+
+// AnnotateClosingDate returns the copy of the closed milestone whose description tells when it was closed,
+// since the API cannot set closed_at of milestones.
+// Milestones not closed are returned as they are.
+func AnnotateClosingDate(m *github.Milestone) *github.Milestone {
+	if m.GetState() != "closed" || m.ClosedAt == nil {
+		return m
+	}
+	note := fmt.Sprintf("_Originally closed at %s_", m.GetClosedAt().UTC().Format(time.RFC3339))
+	description := note
+	if m.GetDescription() != "" {
+		description = fmt.Sprintf("%s\n\n%s", m.GetDescription(), note)
+	}
+	annotated := *m
+	annotated.Description = &description
+	return &annotated
+}
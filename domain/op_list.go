@@ -30,6 +30,15 @@ const (
 	OpCreate  = OpKind("create")
 	OpUpdate  = OpKind("update")
 	OpNothing = OpKind("nothing")
+	OpDelete  = OpKind("delete")
+)
+
+// ConflictPolicy tells which value wins when an entity exists on both repositories with different attributes.
+type ConflictPolicy string
+
+const (
+	SourceWins = ConflictPolicy("source")
+	TargetWins = ConflictPolicy("target")
 )
 
 func stringify(kind OpKind, payload interface{}) string {
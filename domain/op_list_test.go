@@ -3,6 +3,7 @@ package domain
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/go-github/github"
 )
@@ -191,3 +192,74 @@ func TestNewMilestoneOpsList(t *testing.T) {
 		})
 	}
 }
+
+func TestNewMilestonePruneOpsList(t *testing.T) {
+	sourceMilestones := []*github.Milestone{{Title: strRef("v1")}}
+	targetMilestones := []*github.Milestone{{Title: strRef("v1")}, {Title: strRef("someday"), Number: intRef(3)}}
+	want := MilestoneOpsList{
+		{Kind: OpDelete, Milestone: &github.Milestone{Title: strRef("someday"), Number: intRef(3)}},
+	}
+	if got := NewMilestonePruneOpsList(sourceMilestones, targetMilestones); !reflect.DeepEqual(got, want) {
+		t.Errorf("NewMilestonePruneOpsList() = %s, want %s", got, want)
+	}
+}
+
+func TestAnnotateClosingDate(t *testing.T) {
+	closedAt := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name      string
+		milestone *github.Milestone
+		want      string
+	}{
+		{
+			name:      "closed",
+			milestone: &github.Milestone{State: strRef("closed"), Description: strRef("first release"), ClosedAt: &closedAt},
+			want:      "first release\n\n_Originally closed at 2019-01-02T03:04:05Z_",
+		},
+		{
+			name:      "closed without description",
+			milestone: &github.Milestone{State: strRef("closed"), ClosedAt: &closedAt},
+			want:      "_Originally closed at 2019-01-02T03:04:05Z_",
+		},
+		{
+			name:      "open",
+			milestone: &github.Milestone{State: strRef("open"), Description: strRef("next release")},
+			want:      "next release",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			description := tt.milestone.GetDescription()
+			if got := AnnotateClosingDate(tt.milestone).GetDescription(); got != tt.want {
+				t.Errorf("AnnotateClosingDate() = %q, want %q", got, tt.want)
+			}
+			if tt.milestone.GetDescription() != description {
+				t.Errorf("source milestone modified: %q", tt.milestone.GetDescription())
+			}
+		})
+	}
+}
+
+func TestMilestonesToClose(t *testing.T) {
+	closedAt := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	v1 := &github.Milestone{Title: strRef("v1"), State: strRef("closed"), ClosedAt: &closedAt}
+	v2 := &github.Milestone{Title: strRef("v2"), State: strRef("closed"), ClosedAt: &closedAt}
+	v3 := &github.Milestone{Title: strRef("v3"), State: strRef("closed"), ClosedAt: &closedAt}
+	v4 := &github.Milestone{Title: strRef("v4"), State: strRef("open")}
+	sourceMilestones := []*github.Milestone{v1, v2, v3, v4}
+	targetMilestones := []*github.Milestone{
+		// left open by the interrupted run
+		{Number: intRef(1), Title: strRef("v1"), State: strRef("open"), Description: AnnotateClosingDate(v1).Description},
+		{Number: intRef(2), Title: strRef("v2"), State: strRef("closed")},
+		{Number: intRef(3), Title: strRef("v4"), State: strRef("open")},
+	}
+
+	// nothing to create or update for v1, but it must be closed
+	if ops := NewMilestoneOpsList([]*github.Milestone{AnnotateClosingDate(v1)}, targetMilestones[:1]); len(ops) != 0 {
+		t.Errorf("NewMilestoneOpsList() = %s, want no ops", ops)
+	}
+	want := []*github.Milestone{v1, v3}
+	if got := MilestonesToClose(sourceMilestones, targetMilestones); !reflect.DeepEqual(got, want) {
+		t.Errorf("MilestonesToClose() = %v, want %v", got, want)
+	}
+}
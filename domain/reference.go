@@ -0,0 +1,170 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RepositoryLocation tells where the repository is on the web.
+type RepositoryLocation struct {
+	WebURL string // e.g. https://github.com
+	Owner  string
+	Name   string
+}
+
+func (l *RepositoryLocation) String() string {
+	return fmt.Sprintf("%s/%s", l.Owner, l.Name)
+}
+
+func (l *RepositoryLocation) url() string {
+	return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(l.WebURL, "/"), l.Owner, l.Name)
+}
+
+// IssueNumberResolver returns the number on the target repository of the issue migrated from the source one.
+type IssueNumberResolver func(sourceNumber int) (targetNumber int, ok bool)
+
+// ReferenceRewriter translates references to issues, pull requests and commits of the source repository
+// in migrated bodies into ones of the target repository.
+// References to issues not migrated are kept pointing at the source repository.
+type ReferenceRewriter struct {
+	source      *RepositoryLocation
+	target      *RepositoryLocation
+	issueNumber IssueNumberResolver
+	pending     func(sourceNumber int) bool
+	urlPattern  *regexp.Regexp
+	refPattern  *regexp.Regexp
+	linkPattern *regexp.Regexp
+}
+
+func NewReferenceRewriter(source, target *RepositoryLocation, issueNumber IssueNumberResolver) *ReferenceRewriter {
+	return &ReferenceRewriter{
+		source:      source,
+		target:      target,
+		issueNumber: issueNumber,
+		// e.g. https://github.com/owner/repo/issues/1, https://github.com/owner/repo/commit/0123abc
+		urlPattern: regexp.MustCompile(`(?i)` + regexp.QuoteMeta(source.url()) + `/(issues|pull|commit)/([0-9a-f]+)\b`),
+		// e.g. #1, owner/repo#1
+		refPattern: regexp.MustCompile(`(?i)(^|[^\w/.&#-])(` + regexp.QuoteMeta(source.String()) + `)?#(\d+)\b`),
+		// e.g. https://github.com/owner/repo, https://github.com/owner/repo/wiki/Page
+		linkPattern: regexp.MustCompile(`(?i)` + regexp.QuoteMeta(source.url()) + `(/[^\s()<>\[\]"']*)?(\.?(?:[^\w.-]|$))`),
+	}
+}
+
+// pendingPattern matches placeholders left by the rewriter deferring references, e.g. [migrate-gh-repo:#1], [migrate-gh-repo:pull#1]
+var pendingPattern = regexp.MustCompile(`\[migrate-gh-repo:(issues|pull|)#(\d+)\]`)
+
+// Deferring returns the rewriter leaving placeholders for references to issues pending returns true for,
+// i.e. ones to be migrated later whose numbers on the target are not known yet.
+// ResolvePending replaces the placeholders once the issues are migrated.
+func (r *ReferenceRewriter) Deferring(pending func(sourceNumber int) bool) *ReferenceRewriter {
+	deferring := *r
+	deferring.pending = pending
+	return &deferring
+}
+
+// ResolvePending replaces placeholders left by the deferring rewriter with references to the target repository,
+// or to the source repository if the issues have not been migrated.
+func (r *ReferenceRewriter) ResolvePending(body string) string {
+	return pendingPattern.ReplaceAllStringFunc(body, func(s string) string {
+		m := pendingPattern.FindStringSubmatch(s)
+		kind := m[1]
+		n, err := strconv.Atoi(m[2])
+		if err != nil {
+			return s
+		}
+		targetNumber, ok := r.issueNumber(n)
+		switch {
+		case kind == "" && ok:
+			return fmt.Sprintf("#%d", targetNumber)
+		case kind == "":
+			return fmt.Sprintf("%s#%d", r.source, n)
+		case ok:
+			return fmt.Sprintf("%s/issues/%d", r.target.url(), targetNumber)
+		default:
+			return fmt.Sprintf("%s/%s/%d", r.source.url(), kind, n)
+		}
+	})
+}
+
+// PendingReferences returns numbers of source issues referred by placeholders left by the deferring rewriter.
+func PendingReferences(body string) []int {
+	numbers := []int{}
+	for _, m := range pendingPattern.FindAllStringSubmatch(body, -1) {
+		if n, err := strconv.Atoi(m[2]); err == nil {
+			numbers = append(numbers, n)
+		}
+	}
+	return numbers
+}
+
+func (r *ReferenceRewriter) isPending(n int) bool {
+	return r.pending != nil && r.pending(n)
+}
+
+func (r *ReferenceRewriter) Rewrite(body string) string {
+	return mapOutsideCode(body, func(text string) string {
+		text = r.urlPattern.ReplaceAllStringFunc(text, r.rewriteURL)
+		return r.refPattern.ReplaceAllStringFunc(text, r.rewriteRef)
+	})
+}
+
+// RewriteLinks translates links to the source repository into ones to the target repository, e.g. in wiki pages.
+// Unlike Rewrite, bare references such as #1 are kept since they are not linked outside of issues and pull requests.
+func (r *ReferenceRewriter) RewriteLinks(page string) string {
+	return mapOutsideCode(page, func(text string) string {
+		return r.linkPattern.ReplaceAllStringFunc(text, r.rewriteLink)
+	})
+}
+
+func (r *ReferenceRewriter) rewriteLink(s string) string {
+	m := r.linkPattern.FindStringSubmatch(s)
+	path, tail := m[1], m[2]
+	link := strings.TrimSuffix(s, tail)
+	if loc := r.urlPattern.FindStringIndex(link); loc != nil {
+		// links to issues not migrated are kept pointing at the source repository
+		return r.urlPattern.ReplaceAllStringFunc(link, r.rewriteURL) + tail
+	}
+	return r.target.url() + path + tail
+}
+
+func (r *ReferenceRewriter) rewriteURL(s string) string {
+	m := r.urlPattern.FindStringSubmatch(s)
+	kind, id := strings.ToLower(m[1]), m[2]
+	if kind == "commit" {
+		return fmt.Sprintf("%s/commit/%s", r.target.url(), id)
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return s
+	}
+	targetNumber, ok := r.issueNumber(n)
+	if !ok {
+		if r.isPending(n) {
+			return fmt.Sprintf("[migrate-gh-repo:%s#%d]", kind, n)
+		}
+		return s
+	}
+	// the target may have the pull request migrated as an issue; GitHub redirects /issues/N to the pull request if any
+	return fmt.Sprintf("%s/issues/%d", r.target.url(), targetNumber)
+}
+
+func (r *ReferenceRewriter) rewriteRef(s string) string {
+	m := r.refPattern.FindStringSubmatch(s)
+	prefix, qualified, number := m[1], m[2], m[3]
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return s
+	}
+	if targetNumber, ok := r.issueNumber(n); ok {
+		return fmt.Sprintf("%s#%d", prefix, targetNumber)
+	}
+	if r.isPending(n) {
+		return fmt.Sprintf("%s[migrate-gh-repo:#%d]", prefix, n)
+	}
+	if qualified != "" {
+		return s
+	}
+	return fmt.Sprintf("%s%s#%d", prefix, r.source, n)
+}
@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReferenceRewriter_Rewrite(t *testing.T) {
+	source := &RepositoryLocation{WebURL: "https://github.com", Owner: "aereal", Name: "src"}
+	target := &RepositoryLocation{WebURL: "https://ghe.example.com/", Owner: "aereal", Name: "dest"}
+	migrated := map[int]int{1: 1, 2: 5}
+	rewriter := NewReferenceRewriter(source, target, func(n int) (int, bool) {
+		got, ok := migrated[n]
+		return got, ok
+	})
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "short reference",
+			body: "fixes #2 and #1.",
+			want: "fixes #5 and #1.",
+		},
+		{
+			name: "short reference not migrated",
+			body: "see #3",
+			want: "see aereal/src#3",
+		},
+		{
+			name: "qualified reference",
+			body: "see aereal/src#2, Aereal/Src#3 and aereal/other#2",
+			want: "see #5, Aereal/Src#3 and aereal/other#2",
+		},
+		{
+			name: "issue and pull request URLs",
+			body: "https://github.com/aereal/src/issues/2 https://github.com/aereal/src/pull/1#issuecomment-10 https://github.com/aereal/src/issues/3",
+			want: "https://ghe.example.com/aereal/dest/issues/5 https://ghe.example.com/aereal/dest/issues/1#issuecomment-10 https://github.com/aereal/src/issues/3",
+		},
+		{
+			name: "commit URL",
+			body: "reverts https://github.com/aereal/src/commit/0123abc",
+			want: "reverts https://ghe.example.com/aereal/dest/commit/0123abc",
+		},
+		{
+			name: "other repository URL",
+			body: "https://github.com/aereal/srcx/issues/2",
+			want: "https://github.com/aereal/srcx/issues/2",
+		},
+		{
+			name: "not references",
+			body: "&#2; a#2 #2a http://example.com/#2",
+			want: "&#2; a#2 #2a http://example.com/#2",
+		},
+		{
+			name: "code",
+			body: "`#2` #2\n```\n#2\n```\n#2",
+			want: "`#2` #5\n```\n#2\n```\n#5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rewriter.Rewrite(tt.body); got != tt.want {
+				t.Errorf("Rewrite() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReferenceRewriter_RewriteLinks(t *testing.T) {
+	source := &RepositoryLocation{WebURL: "https://github.com", Owner: "aereal", Name: "src"}
+	target := &RepositoryLocation{WebURL: "https://ghe.example.com/", Owner: "aereal", Name: "dest"}
+	rewriter := NewReferenceRewriter(source, target, func(n int) (int, bool) {
+		if n == 2 {
+			return 5, true
+		}
+		return 0, false
+	})
+
+	tests := []struct {
+		name string
+		page string
+		want string
+	}{
+		{
+			name: "wiki and file links",
+			page: "[Home](https://github.com/aereal/src/wiki/Home) and <https://github.com/aereal/src/blob/master/README.md>",
+			want: "[Home](https://ghe.example.com/aereal/dest/wiki/Home) and <https://ghe.example.com/aereal/dest/blob/master/README.md>",
+		},
+		{
+			name: "repository link",
+			page: "moved from https://github.com/aereal/src.",
+			want: "moved from https://ghe.example.com/aereal/dest.",
+		},
+		{
+			name: "issue links",
+			page: "[#2](https://github.com/aereal/src/issues/2) [#3](https://github.com/aereal/src/issues/3)",
+			want: "[#2](https://ghe.example.com/aereal/dest/issues/5) [#3](https://github.com/aereal/src/issues/3)",
+		},
+		{
+			name: "bare references",
+			page: "see #2",
+			want: "see #2",
+		},
+		{
+			name: "other repository",
+			page: "https://github.com/aereal/src-fork/wiki",
+			want: "https://github.com/aereal/src-fork/wiki",
+		},
+		{
+			name: "code",
+			page: "`https://github.com/aereal/src/wiki`",
+			want: "`https://github.com/aereal/src/wiki`",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rewriter.RewriteLinks(tt.page); got != tt.want {
+				t.Errorf("RewriteLinks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReferenceRewriter_Deferring(t *testing.T) {
+	source := &RepositoryLocation{WebURL: "https://github.com", Owner: "aereal", Name: "src"}
+	target := &RepositoryLocation{WebURL: "https://ghe.example.com/", Owner: "aereal", Name: "dest"}
+	migrated := map[int]int{1: 1}
+	rewriter := NewReferenceRewriter(source, target, func(n int) (int, bool) {
+		got, ok := migrated[n]
+		return got, ok
+	})
+	deferring := rewriter.Deferring(func(n int) bool { return n == 2 || n == 3 })
+
+	body := deferring.Rewrite("#1, #2, aereal/src#3, #4, https://github.com/aereal/src/pull/2#discussion_r1 and `#2`")
+	if want := "#1, [migrate-gh-repo:#2], [migrate-gh-repo:#3], aereal/src#4, [migrate-gh-repo:pull#2]#discussion_r1 and `#2`"; body != want {
+		t.Fatalf("Rewrite() = %q, want %q", body, want)
+	}
+	if got := PendingReferences(body); !reflect.DeepEqual(got, []int{2, 3, 2}) {
+		t.Errorf("PendingReferences() = %v", got)
+	}
+
+	// #2 has been migrated as #5 but #3 has not
+	migrated[2] = 5
+	if got, want := rewriter.ResolvePending(body), "#1, #5, aereal/src#3, aereal/src#4, https://ghe.example.com/aereal/dest/issues/5#discussion_r1 and `#2`"; got != want {
+		t.Errorf("ResolvePending() = %q, want %q", got, want)
+	}
+}
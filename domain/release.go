@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/google/go-github/github"
+)
+
+type release struct {
+	*github.RepositoryRelease
+}
+
+func (r *release) Key() *Key {
+	if r == nil {
+		return nil
+	}
+	return &Key{kind: "release", repr: r.GetTagName()}
+}
+
+// NewReleaseOpsList tells releases to be created and ones already on the target.
+// Releases are matched by tag names; already migrated ones are told OpUpdate with TargetRelease to migrate missing assets.
+func NewReleaseOpsList(sourceReleases, targetReleases []*github.RepositoryRelease) ReleaseOpsList {
+	if len(sourceReleases) == 0 && len(targetReleases) == 0 {
+		return nil
+	}
+
+	kinds := opMapping{}
+	mapping := map[string]*github.RepositoryRelease{}
+	for _, s := range sourceReleases {
+		src := &release{s}
+		kinds.requestCreate(src)
+		for _, t := range targetReleases {
+			target := &release{t}
+			if src.Key().Eq(target.Key()) {
+				kinds.requestUpdate(src)
+				mapping[src.Key().String()] = t
+			}
+		}
+	}
+
+	ops := []*ReleaseOp{}
+	for _, s := range sourceReleases {
+		src := &release{s}
+		switch kinds.get(src) {
+		case OpCreate:
+			ops = append(ops, &ReleaseOp{
+				Kind:    OpCreate,
+				Release: s,
+			})
+		case OpUpdate:
+			ops = append(ops, &ReleaseOp{
+				Kind:          OpUpdate,
+				Release:       s,
+				TargetRelease: mapping[src.Key().String()],
+			})
+		default:
+		}
+	}
+	return ops
+}
+
+type ReleaseOpsList []*ReleaseOp
+
+func (l ReleaseOpsList) String() string {
+	s := "["
+	for _, op := range l {
+		s += fmt.Sprintf("%s, ", op)
+	}
+	s += "]"
+	return s
+}
+
+type ReleaseOp struct {
+	Kind          OpKind
+	Release       *github.RepositoryRelease
+	TargetRelease *github.RepositoryRelease // nil unless Kind is OpUpdate
+}
+
+func (op *ReleaseOp) String() string {
+	return stringify(op.Kind, op.Release)
+}
+
+// MissingReleaseAssets returns assets of the source release not uploaded to the target release, matched by names.
+func MissingReleaseAssets(sourceRelease, targetRelease *github.RepositoryRelease) []github.ReleaseAsset {
+	uploaded := map[string]bool{}
+	if targetRelease != nil {
+		for _, a := range targetRelease.Assets {
+			uploaded[a.GetName()] = true
+		}
+	}
+	missing := []github.ReleaseAsset{}
+	for _, a := range sourceRelease.Assets {
+		if !uploaded[a.GetName()] {
+			missing = append(missing, a)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/github"
+)
+
+func TestNewReleaseOpsList(t *testing.T) {
+	v1 := &github.RepositoryRelease{TagName: strRef("v1")}
+	v2 := &github.RepositoryRelease{TagName: strRef("v2")}
+	migratedV1 := &github.RepositoryRelease{TagName: strRef("v1"), ID: int64Ref(100)}
+	tests := []struct {
+		name           string
+		sourceReleases []*github.RepositoryRelease
+		targetReleases []*github.RepositoryRelease
+		want           ReleaseOpsList
+	}{
+		{
+			name: "empty",
+			want: nil,
+		},
+		{
+			name:           "source=[v1,v2] target=[v1]",
+			sourceReleases: []*github.RepositoryRelease{v1, v2},
+			targetReleases: []*github.RepositoryRelease{migratedV1},
+			want: ReleaseOpsList{
+				&ReleaseOp{Kind: OpUpdate, Release: v1, TargetRelease: migratedV1},
+				&ReleaseOp{Kind: OpCreate, Release: v2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewReleaseOpsList(tt.sourceReleases, tt.targetReleases); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewReleaseOpsList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMissingReleaseAssets(t *testing.T) {
+	source := &github.RepositoryRelease{Assets: []github.ReleaseAsset{{Name: strRef("a.tar.gz")}, {Name: strRef("b.zip")}}}
+	target := &github.RepositoryRelease{Assets: []github.ReleaseAsset{{Name: strRef("a.tar.gz")}}}
+
+	if got, want := MissingReleaseAssets(source, target), []github.ReleaseAsset{{Name: strRef("b.zip")}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MissingReleaseAssets() = %v, want %v", got, want)
+	}
+	if got := MissingReleaseAssets(source, nil); !reflect.DeepEqual(got, source.Assets) {
+		t.Errorf("MissingReleaseAssets() for new release = %v, want %v", got, source.Assets)
+	}
+}
@@ -0,0 +1,264 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+const (
+	markerKindReview       = "review"
+	markerKindReviewThread = "review_thread"
+	// markerKindReviewReply points the last comment of the thread migrated so far, to find replies added later
+	markerKindReviewReply = "review_reply"
+)
+
+// ReviewThread is a line-level review comment and replies to it.
+type ReviewThread struct {
+	Comments []*github.PullRequestComment // the first one starts the thread
+}
+
+func (t *ReviewThread) Root() *github.PullRequestComment {
+	return t.Comments[0]
+}
+
+// NewReviewThreads groups review comments into threads ordered by when they started.
+func NewReviewThreads(comments []*github.PullRequestComment) []*ReviewThread {
+	sorted := make([]*github.PullRequestComment, len(comments))
+	copy(sorted, comments)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].GetCreatedAt().Before(sorted[j].GetCreatedAt()) })
+
+	threads := []*ReviewThread{}
+	byID := map[int64]*ReviewThread{}
+	for _, c := range sorted {
+		if t, ok := byID[c.GetInReplyTo()]; ok && c.GetInReplyTo() != 0 {
+			t.Comments = append(t.Comments, c)
+			byID[c.GetID()] = t
+			continue
+		}
+		t := &ReviewThread{Comments: []*github.PullRequestComment{c}}
+		threads = append(threads, t)
+		byID[c.GetID()] = t
+	}
+	return threads
+}
+
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// Line returns the line in the file the thread is about; the diff hunk of a review comment ends with the line.
+func (t *ReviewThread) Line() (line int, ok bool) {
+	lines := strings.Split(strings.TrimRight(t.Root().GetDiffHunk(), "\n"), "\n")
+	m := hunkHeaderPattern.FindStringSubmatch(lines[0])
+	if m == nil || len(lines) < 2 {
+		return 0, false
+	}
+	oldLine, _ := strconv.Atoi(m[1])
+	newLine, _ := strconv.Atoi(m[2])
+	oldLine--
+	newLine--
+	last := ""
+	for _, l := range lines[1:] {
+		switch {
+		case strings.HasPrefix(l, "-"):
+			oldLine++
+		case strings.HasPrefix(l, "+"):
+			newLine++
+		default:
+			oldLine++
+			newLine++
+		}
+		last = l
+	}
+	if strings.HasPrefix(last, "-") {
+		return oldLine, true
+	}
+	return newLine, true
+}
+
+// Render renders the thread as a comment; each comment is prefixed with the attribution header and its body is passed through rewrite.
+func (t *ReviewThread) Render(header *AttributionTemplate, rewrite func(string) string) (string, error) {
+	location := t.location()
+	fence := "```"
+	if strings.Contains(t.Root().GetDiffHunk(), fence) {
+		fence = "~~~"
+	}
+	return t.render(header, rewrite, t.Comments, fmt.Sprintf("%s\n\n%sdiff\n%s\n%s", location, fence, strings.TrimRight(t.Root().GetDiffHunk(), "\n"), fence))
+}
+
+// RenderReplies renders replies added to the thread after it was migrated as a comment following the migrated one.
+func (t *ReviewThread) RenderReplies(header *AttributionTemplate, rewrite func(string) string, replies []*github.PullRequestComment) (string, error) {
+	return t.render(header, rewrite, replies, fmt.Sprintf("Replies to the thread on %s", t.location()))
+}
+
+func (t *ReviewThread) location() string {
+	location := fmt.Sprintf("`%s`", t.Root().GetPath())
+	if line, ok := t.Line(); ok {
+		location = fmt.Sprintf("%s line %d", location, line)
+	}
+	return location
+}
+
+// render renders comments of the thread with the leading text prepended to the first one.
+func (t *ReviewThread) render(header *AttributionTemplate, rewrite func(string) string, comments []*github.PullRequestComment, leading string) (string, error) {
+	parts := []string{}
+	for i, c := range comments {
+		body := rewrite(c.GetBody())
+		if i == 0 {
+			body = fmt.Sprintf("%s\n\n%s", leading, body)
+		}
+		rendered, err := header.Render(NewAttribution(c.GetUser().GetLogin(), c.GetCreatedAt(), c.GetHTMLURL()), body)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, rendered)
+	}
+	body := EmbedMarker(strings.Join(parts, "\n\n---\n\n"), markerKindReviewThread, strconv.FormatInt(t.Root().GetID(), 10))
+	return EmbedMarker(body, markerKindReviewReply, strconv.FormatInt(comments[len(comments)-1].GetID(), 10)), nil
+}
+
+var reviewVerdicts = map[string]string{
+	"APPROVED":          "Approved",
+	"CHANGES_REQUESTED": "Requested changes",
+	"DISMISSED":         "Dismissed",
+	"COMMENTED":         "Commented",
+}
+
+// RenderReview renders the review as a comment with the attribution header and its verdict.
+func RenderReview(review *github.PullRequestReview, header *AttributionTemplate, rewrite func(string) string) (string, error) {
+	body := fmt.Sprintf("**%s**", reviewVerdicts[review.GetState()])
+	if review.GetBody() != "" {
+		body = fmt.Sprintf("%s\n\n%s", body, rewrite(review.GetBody()))
+	}
+	rendered, err := header.Render(NewAttribution(review.GetUser().GetLogin(), review.GetSubmittedAt(), review.GetHTMLURL()), body)
+	if err != nil {
+		return "", err
+	}
+	return EmbedMarker(rendered, markerKindReview, strconv.FormatInt(review.GetID(), 10)), nil
+}
+
+type reviewEntry struct {
+	kind string
+	id   string
+}
+
+func (e *reviewEntry) Key() *Key {
+	if e == nil || e.id == "" {
+		return nil
+	}
+	return &Key{kind: e.kind, repr: e.id}
+}
+
+// NewReviewOpsList tells reviews and review threads not migrated yet to the target issue, ordered by time.
+// Reviews without body are omitted unless they approved, requested changes or were dismissed.
+// Target comments are matched by the marker embedded by RenderReview and ReviewThread.Render.
+// Replies added to threads after they were migrated are told as ops with Replies, except for threads migrated by older versions without the marker of the last reply.
+func NewReviewOpsList(reviews []*github.PullRequestReview, threads []*ReviewThread, targetComments []*github.IssueComment) ReviewOpsList {
+	kinds := opMapping{}
+	ops := []*ReviewOp{}
+	for _, r := range reviews {
+		if _, ok := reviewVerdicts[r.GetState()]; !ok {
+			continue // pending
+		}
+		if r.GetState() == "COMMENTED" && r.GetBody() == "" {
+			continue // only line comments, which are migrated as threads
+		}
+		e := &reviewEntry{kind: markerKindReview, id: strconv.FormatInt(r.GetID(), 10)}
+		kinds.requestCreate(e)
+		ops = append(ops, &ReviewOp{Kind: OpCreate, Review: r})
+	}
+	for _, t := range threads {
+		e := &reviewEntry{kind: markerKindReviewThread, id: strconv.FormatInt(t.Root().GetID(), 10)}
+		kinds.requestCreate(e)
+		ops = append(ops, &ReviewOp{Kind: OpCreate, Thread: t})
+	}
+	// the last comment of each thread migrated so far; 0 if unknown since migrated by older versions
+	lastReplies := map[string]int64{}
+	for _, c := range targetComments {
+		for _, kind := range []string{markerKindReview, markerKindReviewThread} {
+			if id, ok := ExtractMarker(c.GetBody(), kind); ok {
+				kinds.requestNothing(&reviewEntry{kind: kind, id: id})
+			}
+		}
+		if root, ok := ExtractMarker(c.GetBody(), markerKindReviewThread); ok {
+			reply, _ := ExtractMarker(c.GetBody(), markerKindReviewReply)
+			last, _ := strconv.ParseInt(reply, 10, 64)
+			if prev, ok := lastReplies[root]; !ok || last > prev {
+				lastReplies[root] = last
+			}
+		}
+	}
+
+	l := ReviewOpsList{}
+	for _, op := range ops {
+		if kinds.get(op.entry()) == OpCreate {
+			l = append(l, op)
+			continue
+		}
+		if op.Thread == nil {
+			continue
+		}
+		// replies added on the source after the thread was migrated
+		if last := lastReplies[op.entry().id]; last != 0 {
+			replies := []*github.PullRequestComment{}
+			for _, c := range op.Thread.Comments {
+				if c.GetID() > last {
+					replies = append(replies, c)
+				}
+			}
+			if len(replies) > 0 {
+				l = append(l, &ReviewOp{Kind: OpCreate, Thread: op.Thread, Replies: replies})
+			}
+		}
+	}
+	sort.SliceStable(l, func(i, j int) bool { return l[i].CreatedAt().Before(l[j].CreatedAt()) })
+	return l
+}
+
+type ReviewOpsList []*ReviewOp
+
+func (l ReviewOpsList) String() string {
+	s := "["
+	for _, op := range l {
+		s += fmt.Sprintf("%s, ", op)
+	}
+	s += "]"
+	return s
+}
+
+// ReviewOp has either of Review or Thread.
+// Replies are set if the thread has been migrated and only replies added after that are to be migrated.
+type ReviewOp struct {
+	Kind    OpKind
+	Review  *github.PullRequestReview
+	Thread  *ReviewThread
+	Replies []*github.PullRequestComment
+}
+
+func (op *ReviewOp) entry() *reviewEntry {
+	if op.Review != nil {
+		return &reviewEntry{kind: markerKindReview, id: strconv.FormatInt(op.Review.GetID(), 10)}
+	}
+	return &reviewEntry{kind: markerKindReviewThread, id: strconv.FormatInt(op.Thread.Root().GetID(), 10)}
+}
+
+func (op *ReviewOp) CreatedAt() time.Time {
+	if op.Review != nil {
+		return op.Review.GetSubmittedAt()
+	}
+	if len(op.Replies) > 0 {
+		return op.Replies[0].GetCreatedAt()
+	}
+	return op.Thread.Root().GetCreatedAt()
+}
+
+func (op *ReviewOp) String() string {
+	if op.Review != nil {
+		return stringify(op.Kind, op.Review)
+	}
+	return stringify(op.Kind, op.Thread.Root())
+}
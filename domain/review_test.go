@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+func timeRef(t time.Time) *time.Time { return &t }
+
+func TestNewReviewThreads(t *testing.T) {
+	at := func(min int) *time.Time { return timeRef(time.Date(2019, 10, 1, 12, min, 0, 0, time.UTC)) }
+	root1 := &github.PullRequestComment{ID: int64Ref(1), CreatedAt: at(0)}
+	root2 := &github.PullRequestComment{ID: int64Ref(2), CreatedAt: at(1)}
+	reply1 := &github.PullRequestComment{ID: int64Ref(3), InReplyTo: int64Ref(1), CreatedAt: at(2)}
+	reply3 := &github.PullRequestComment{ID: int64Ref(4), InReplyTo: int64Ref(3), CreatedAt: at(3)}
+
+	got := NewReviewThreads([]*github.PullRequestComment{reply3, root2, reply1, root1})
+	want := []*ReviewThread{
+		{Comments: []*github.PullRequestComment{root1, reply1, reply3}},
+		{Comments: []*github.PullRequestComment{root2}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewReviewThreads() = %v, want %v", got, want)
+	}
+}
+
+func TestReviewThread_Line(t *testing.T) {
+	tests := []struct {
+		name     string
+		diffHunk string
+		wantLine int
+		wantOK   bool
+	}{
+		{
+			name:     "added line",
+			diffHunk: "@@ -10,3 +10,4 @@ func main() {\n \ta := 1\n-\tb := 2\n+\tb := 3\n+\tc := 4",
+			wantLine: 12,
+			wantOK:   true,
+		},
+		{
+			name:     "removed line",
+			diffHunk: "@@ -10,3 +10,2 @@\n \ta := 1\n-\tb := 2",
+			wantLine: 11,
+			wantOK:   true,
+		},
+		{
+			name:     "no hunk",
+			diffHunk: "",
+			wantOK:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thread := &ReviewThread{Comments: []*github.PullRequestComment{{DiffHunk: strRef(tt.diffHunk)}}}
+			line, ok := thread.Line()
+			if line != tt.wantLine || ok != tt.wantOK {
+				t.Errorf("Line() = (%d, %v), want (%d, %v)", line, ok, tt.wantLine, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestReviewThread_Render(t *testing.T) {
+	header, err := NewAttributionTemplate("by {{.Author}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	thread := &ReviewThread{Comments: []*github.PullRequestComment{
+		{ID: int64Ref(1), Path: strRef("main.go"), DiffHunk: strRef("@@ -1,1 +1,1 @@\n-a\n+b"), Body: strRef("why?"), User: &github.User{Login: strRef("aereal")}},
+		{ID: int64Ref(2), Body: strRef("because"), User: &github.User{Login: strRef("noreal")}},
+	}}
+	got, err := thread.Render(header, func(s string) string { return s })
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "by aereal\n\n`main.go` line 1\n\n```diff\n@@ -1,1 +1,1 @@\n-a\n+b\n```\n\nwhy?\n\n---\n\nby noreal\n\nbecause\n\n<!-- migrate-gh-repo:review_thread=1 -->\n\n<!-- migrate-gh-repo:review_reply=2 -->"
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	got, err = thread.RenderReplies(header, func(s string) string { return s }, thread.Comments[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "by noreal\n\nReplies to the thread on `main.go` line 1\n\nbecause\n\n<!-- migrate-gh-repo:review_thread=1 -->\n\n<!-- migrate-gh-repo:review_reply=2 -->"
+	if got != want {
+		t.Errorf("RenderReplies() = %q, want %q", got, want)
+	}
+}
+
+func TestNewReviewOpsList(t *testing.T) {
+	at := func(min int) *time.Time { return timeRef(time.Date(2019, 10, 1, 12, min, 0, 0, time.UTC)) }
+	approved := &github.PullRequestReview{ID: int64Ref(10), State: strRef("APPROVED"), SubmittedAt: at(3)}
+	commented := &github.PullRequestReview{ID: int64Ref(11), State: strRef("COMMENTED"), Body: strRef("nice"), SubmittedAt: at(1)}
+	lineOnly := &github.PullRequestReview{ID: int64Ref(12), State: strRef("COMMENTED"), SubmittedAt: at(0)}
+	pending := &github.PullRequestReview{ID: int64Ref(13), State: strRef("PENDING"), Body: strRef("draft")}
+	migrated := &github.PullRequestReview{ID: int64Ref(14), State: strRef("CHANGES_REQUESTED"), SubmittedAt: at(0)}
+	thread := &ReviewThread{Comments: []*github.PullRequestComment{{ID: int64Ref(20), CreatedAt: at(2)}}}
+	migratedThread := &ReviewThread{Comments: []*github.PullRequestComment{{ID: int64Ref(21), CreatedAt: at(0)}, {ID: int64Ref(24), CreatedAt: at(5)}}}
+	reply := &github.PullRequestComment{ID: int64Ref(26), CreatedAt: at(6)}
+	// replies to 22 were migrated up to 25 in two comments, and 26 is added later
+	repliedThread := &ReviewThread{Comments: []*github.PullRequestComment{{ID: int64Ref(22), CreatedAt: at(0)}, {ID: int64Ref(23), CreatedAt: at(1)}, {ID: int64Ref(25), CreatedAt: at(4)}, reply}}
+	targetComments := []*github.IssueComment{
+		{Body: strRef(EmbedMarker("old", markerKindReview, "14"))},
+		// migrated by the older version without the marker of the last reply
+		{Body: strRef(EmbedMarker("old", markerKindReviewThread, "21"))},
+		{Body: strRef(EmbedMarker(EmbedMarker("old", markerKindReviewThread, "22"), markerKindReviewReply, "23"))},
+		{Body: strRef(EmbedMarker(EmbedMarker("old", markerKindReviewThread, "22"), markerKindReviewReply, "25"))},
+		{Body: strRef("written on target")},
+	}
+
+	got := NewReviewOpsList([]*github.PullRequestReview{approved, commented, lineOnly, pending, migrated}, []*ReviewThread{thread, migratedThread, repliedThread}, targetComments)
+	want := ReviewOpsList{
+		{Kind: OpCreate, Review: commented},
+		{Kind: OpCreate, Thread: thread},
+		{Kind: OpCreate, Review: approved},
+		{Kind: OpCreate, Thread: repliedThread, Replies: []*github.PullRequestComment{reply}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewReviewOpsList() = %v, want %v", got, want)
+	}
+}
@@ -8,22 +8,55 @@ import (
 	"github.com/google/go-github/github"
 )
 
-func NewGitHubService(client *github.Client) (*GitHubService, error) {
+func NewGitHubService(client *github.Client, retry *RetryPolicy) (*GitHubService, error) {
 	if client == nil {
 		return nil, errors.New("client (*github.Client) must be given")
 	}
-	return &GitHubService{client: client}, nil
+	return &GitHubService{client: client, retry: retry}, nil
 }
 
 type GitHubService struct {
 	client *github.Client
+	retry  *RetryPolicy
+}
+
+func (s *GitHubService) GetRepository(ctx context.Context, owner, repo string) (*github.Repository, error) {
+	var r *github.Repository
+	err := s.retry.Do(ctx, func() error {
+		var err error
+		r, _, err = s.client.Repositories.Get(ctx, owner, repo)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository: %w", err)
+	}
+	return r, nil
+}
+
+func (s *GitHubService) GetPullRequest(ctx context.Context, owner, repo string, number int) (*github.PullRequest, error) {
+	var pr *github.PullRequest
+	err := s.retry.Do(ctx, func() error {
+		var err error
+		pr, _, err = s.client.PullRequests.Get(ctx, owner, repo, number)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pull request: %w", err)
+	}
+	return pr, nil
 }
 
 func (s *GitHubService) SlurpMilestones(ctx context.Context, owner, repo string) ([]*github.Milestone, error) {
 	opts := &github.MilestoneListOptions{State: "all", ListOptions: github.ListOptions{PerPage: 100}}
 	milestones := []*github.Milestone{}
 	for {
-		ms, resp, err := s.client.Issues.ListMilestones(ctx, owner, repo, opts)
+		var ms []*github.Milestone
+		var resp *github.Response
+		err := s.retry.Do(ctx, func() error {
+			var err error
+			ms, resp, err = s.client.Issues.ListMilestones(ctx, owner, repo, opts)
+			return err
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to list milestones: %w", err)
 		}
@@ -40,7 +73,13 @@ func (s *GitHubService) SlurpLabels(ctx context.Context, owner, repo string) ([]
 	opts := &github.ListOptions{PerPage: 100}
 	labels := []*github.Label{}
 	for {
-		ls, resp, err := s.client.Issues.ListLabels(ctx, owner, repo, opts)
+		var ls []*github.Label
+		var resp *github.Response
+		err := s.retry.Do(ctx, func() error {
+			var err error
+			ls, resp, err = s.client.Issues.ListLabels(ctx, owner, repo, opts)
+			return err
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to list labels: %w", err)
 		}
@@ -57,7 +96,13 @@ func (s *GitHubService) SlurpIssues(ctx context.Context, owner, repo string) ([]
 	opts := &github.IssueListByRepoOptions{State: "all", Direction: "asc", ListOptions: github.ListOptions{PerPage: 100}}
 	issues := []*github.Issue{}
 	for {
-		is, resp, err := s.client.Issues.ListByRepo(ctx, owner, repo, opts)
+		var is []*github.Issue
+		var resp *github.Response
+		err := s.retry.Do(ctx, func() error {
+			var err error
+			is, resp, err = s.client.Issues.ListByRepo(ctx, owner, repo, opts)
+			return err
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to list issues: %w", err)
 		}
@@ -74,7 +119,13 @@ func (s *GitHubService) SlurpIssueComments(ctx context.Context, owner, repo stri
 	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
 	issueComments := []*github.IssueComment{}
 	for {
-		comments, resp, err := s.client.Issues.ListComments(ctx, owner, repo, issueNumber, opts)
+		var comments []*github.IssueComment
+		var resp *github.Response
+		err := s.retry.Do(ctx, func() error {
+			var err error
+			comments, resp, err = s.client.Issues.ListComments(ctx, owner, repo, issueNumber, opts)
+			return err
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to list issue comments: %w", err)
 		}
@@ -87,11 +138,63 @@ func (s *GitHubService) SlurpIssueComments(ctx context.Context, owner, repo stri
 	return issueComments, nil
 }
 
+func (s *GitHubService) SlurpPullRequestReviews(ctx context.Context, owner, repo string, number int) ([]*github.PullRequestReview, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	reviews := []*github.PullRequestReview{}
+	for {
+		var rs []*github.PullRequestReview
+		var resp *github.Response
+		err := s.retry.Do(ctx, func() error {
+			var err error
+			rs, resp, err = s.client.PullRequests.ListReviews(ctx, owner, repo, number, opts)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pull request reviews: %w", err)
+		}
+		reviews = append(reviews, rs...)
+		opts.Page = resp.NextPage
+		if resp.NextPage == 0 {
+			break
+		}
+	}
+	return reviews, nil
+}
+
+func (s *GitHubService) SlurpPullRequestComments(ctx context.Context, owner, repo string, number int) ([]*github.PullRequestComment, error) {
+	opts := &github.PullRequestListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	prComments := []*github.PullRequestComment{}
+	for {
+		var comments []*github.PullRequestComment
+		var resp *github.Response
+		err := s.retry.Do(ctx, func() error {
+			var err error
+			comments, resp, err = s.client.PullRequests.ListComments(ctx, owner, repo, number, opts)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pull request review comments: %w", err)
+		}
+		prComments = append(prComments, comments...)
+		opts.Page = resp.NextPage
+		if resp.NextPage == 0 {
+			break
+		}
+	}
+	return prComments, nil
+}
+
 func (s *GitHubService) SlurpProjects(ctx context.Context, owner, repo string) ([]*github.Project, error) {
 	opts := &github.ProjectListOptions{State: "all", ListOptions: github.ListOptions{PerPage: 100}}
 	projects := []*github.Project{}
 	for {
-		pjs, resp, err := s.client.Repositories.ListProjects(ctx, owner, repo, opts)
+		var pjs []*github.Project
+		var resp *github.Response
+		err := s.retry.Do(ctx, func() error {
+			var err error
+			pjs, resp, err = s.client.Repositories.ListProjects(ctx, owner, repo, opts)
+			return err
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to list projects: %w", err)
 		}
@@ -108,7 +211,13 @@ func (s *GitHubService) SlurpProjectColumns(ctx context.Context, projectID int64
 	opts := &github.ListOptions{PerPage: 100}
 	columns := []*github.ProjectColumn{}
 	for {
-		cols, resp, err := s.client.Projects.ListProjectColumns(ctx, projectID, opts)
+		var cols []*github.ProjectColumn
+		var resp *github.Response
+		err := s.retry.Do(ctx, func() error {
+			var err error
+			cols, resp, err = s.client.Projects.ListProjectColumns(ctx, projectID, opts)
+			return err
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to list project columns: %w", err)
 		}
@@ -125,7 +234,13 @@ func (s *GitHubService) SlurpProjectCards(ctx context.Context, columnID int64) (
 	opts := &github.ProjectCardListOptions{ListOptions: github.ListOptions{PerPage: 100}}
 	cards := []*github.ProjectCard{}
 	for {
-		cs, resp, err := s.client.Projects.ListProjectCards(ctx, columnID, opts)
+		var cs []*github.ProjectCard
+		var resp *github.Response
+		err := s.retry.Do(ctx, func() error {
+			var err error
+			cs, resp, err = s.client.Projects.ListProjectCards(ctx, columnID, opts)
+			return err
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to list project cards: %w", err)
 		}
@@ -137,3 +252,26 @@ func (s *GitHubService) SlurpProjectCards(ctx context.Context, columnID int64) (
 	}
 	return cards, nil
 }
+
+func (s *GitHubService) SlurpReleases(ctx context.Context, owner, repo string) ([]*github.RepositoryRelease, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	releases := []*github.RepositoryRelease{}
+	for {
+		var rs []*github.RepositoryRelease
+		var resp *github.Response
+		err := s.retry.Do(ctx, func() error {
+			var err error
+			rs, resp, err = s.client.Repositories.ListReleases(ctx, owner, repo, opts)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list releases: %w", err)
+		}
+		releases = append(releases, rs...)
+		opts.Page = resp.NextPage
+		if resp.NextPage == 0 {
+			break
+		}
+	}
+	return releases, nil
+}
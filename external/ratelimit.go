@@ -0,0 +1,175 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aereal/migrate-gh-repo/logging"
+)
+
+const (
+	headerRateRemaining = "X-RateLimit-Remaining"
+	headerRateReset     = "X-RateLimit-Reset"
+	headerRetryAfter    = "Retry-After"
+
+	// GitHub does not always tell how long to wait after the secondary rate limit (a.k.a. abuse detection) is hit
+	defaultSecondaryRateLimitWait = time.Minute
+	// a margin for clock skew between GitHub and us
+	resetMargin = time.Second
+)
+
+// RateLimitTransport holds requests while the rate limit of GitHub API is exhausted
+// and retries requests rejected by the secondary rate limit after the duration GitHub tells.
+type RateLimitTransport struct {
+	Base       http.RoundTripper
+	MaxRetries int
+
+	mu           sync.Mutex
+	blockedUntil time.Time
+	now          func() time.Time
+	sleep        func(ctx context.Context, d time.Duration) error
+}
+
+func NewRateLimitTransport(base http.RoundTripper) *RateLimitTransport {
+	return &RateLimitTransport{
+		Base:       base,
+		MaxRetries: 3,
+		now:        time.Now,
+		sleep:      sleepContext,
+	}
+}
+
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := t.waitForReset(req.Context()); err != nil {
+			return nil, err
+		}
+
+		resp, err := t.base().RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		t.observe(resp)
+
+		wait, limited := t.retryAfter(resp)
+		if !limited || attempt >= t.MaxRetries {
+			return resp, nil
+		}
+		retry, ok := rewind(req)
+		if !ok {
+			return resp, nil
+		}
+		resp.Body.Close()
+		logging.Infof("rate limited on %s %s; retry after %s", req.Method, req.URL.Path, wait)
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		req = retry
+	}
+}
+
+func (t *RateLimitTransport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+func (t *RateLimitTransport) waitForReset(ctx context.Context) error {
+	t.mu.Lock()
+	wait := t.blockedUntil.Sub(t.now())
+	t.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	logging.Infof("rate limit exhausted; wait %s until reset", wait)
+	return t.sleep(ctx, wait)
+}
+
+// observe blocks following requests until the rate limit is reset if no more requests remain.
+func (t *RateLimitTransport) observe(resp *http.Response) {
+	if resp.Header.Get(headerRateRemaining) != "0" {
+		return
+	}
+	reset, ok := parseUnixTime(resp.Header.Get(headerRateReset))
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until := reset.Add(resetMargin); until.After(t.blockedUntil) {
+		t.blockedUntil = until
+	}
+}
+
+func (t *RateLimitTransport) retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if v := resp.Header.Get(headerRetryAfter); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second, true
+		}
+	}
+	if resp.Header.Get(headerRateRemaining) == "0" {
+		// observe() has already blocked requests until the reset
+		return 0, true
+	}
+	if isSecondaryRateLimited(resp) {
+		return defaultSecondaryRateLimitWait, true
+	}
+	return 0, false
+}
+
+func isSecondaryRateLimited(resp *http.Response) bool {
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		return false
+	}
+	msg := strings.ToLower(string(b))
+	return strings.Contains(msg, "secondary rate limit") || strings.Contains(msg, "abuse")
+}
+
+// rewind returns a copy of the request to send again; requests that have a body which cannot be read twice cannot be retried.
+func rewind(req *http.Request) (*http.Request, bool) {
+	retry := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return retry, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	retry.Body = body
+	return retry, true
+}
+
+func parseUnixTime(s string) (time.Time, bool) {
+	secs, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(secs, 0), true
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,130 @@
+package external
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestTransport(now time.Time) (*RateLimitTransport, *[]time.Duration) {
+	slept := []time.Duration{}
+	t := NewRateLimitTransport(http.DefaultTransport)
+	t.now = func() time.Time { return now }
+	t.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	return t, &slept
+}
+
+func TestRateLimitTransport_waitsForReset(t *testing.T) {
+	now := time.Unix(1000, 0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerRateRemaining, "0")
+		w.Header().Set(headerRateReset, "1030")
+		fmt.Fprint(w, "{}")
+	}))
+	defer srv.Close()
+
+	tr, slept := newTestTransport(now)
+	client := &http.Client{Transport: tr}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	want := []time.Duration{31 * time.Second}
+	if fmt.Sprint(*slept) != fmt.Sprint(want) {
+		t.Errorf("slept = %v, want %v", *slept, want)
+	}
+}
+
+func TestRateLimitTransport_retriesSecondaryRateLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    http.Header
+		body      string
+		wantSlept []time.Duration
+	}{
+		{
+			name:      "Retry-After",
+			header:    http.Header{headerRetryAfter: []string{"5"}},
+			body:      `{"message":"You have exceeded a secondary rate limit."}`,
+			wantSlept: []time.Duration{5 * time.Second},
+		},
+		{
+			name:      "abuse detection without Retry-After",
+			header:    http.Header{},
+			body:      `{"message":"You have triggered an abuse detection mechanism."}`,
+			wantSlept: []time.Duration{defaultSecondaryRateLimitWait},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			bodies := []string{}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				bodies = append(bodies, string(b))
+				calls++
+				if calls == 1 {
+					for k, vs := range tt.header {
+						w.Header()[k] = vs
+					}
+					w.WriteHeader(http.StatusForbidden)
+					fmt.Fprint(w, tt.body)
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer srv.Close()
+
+			tr, slept := newTestTransport(time.Unix(1000, 0))
+			client := &http.Client{Transport: tr}
+			resp, err := client.Post(srv.URL, "application/json", strings.NewReader(`{"title":"poppoe"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusCreated {
+				t.Errorf("StatusCode = %d, want %d", resp.StatusCode, http.StatusCreated)
+			}
+			if fmt.Sprint(*slept) != fmt.Sprint(tt.wantSlept) {
+				t.Errorf("slept = %v, want %v", *slept, tt.wantSlept)
+			}
+			if len(bodies) != 2 || bodies[0] != bodies[1] {
+				t.Errorf("request bodies = %q", bodies)
+			}
+		})
+	}
+}
+
+func TestRateLimitTransport_passesForbidden(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"Must have admin rights to Repository."}`)
+	}))
+	defer srv.Close()
+
+	tr, slept := newTestTransport(time.Unix(1000, 0))
+	client := &http.Client{Transport: tr}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	if !strings.Contains(string(b), "admin rights") {
+		t.Errorf("body = %q", string(b))
+	}
+	if len(*slept) != 0 {
+		t.Errorf("slept = %v, want none", *slept)
+	}
+}
@@ -0,0 +1,179 @@
+package external
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/aereal/migrate-gh-repo/logging"
+	"github.com/google/go-github/github"
+)
+
+// RetryPolicy retries transient failures with exponential backoff and jitter.
+// A nil *RetryPolicy calls the function just once.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+	jitter func(d time.Duration) time.Duration
+}
+
+func NewRetryPolicy(maxAttempts int, baseDelay, maxDelay time.Duration) *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: maxAttempts,
+		BaseDelay:   baseDelay,
+		MaxDelay:    maxDelay,
+		now:         time.Now,
+		sleep:       sleepContext,
+		jitter:      equalJitter,
+	}
+}
+
+// maxRateLimitWaits bounds waits for the reset of the rate limit which do not count as attempts.
+const maxRateLimitWaits = 3
+
+// Do calls fn again on transient failures; fn must be idempotent since the failed call may have taken effect (e.g. 502 after the change is committed).
+func (p *RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	return p.do(ctx, fn, IsRetryable)
+}
+
+// DoOnce calls fn again only if the call was rejected without any effect (i.e. rate limits), for non-idempotent calls such as creations.
+func (p *RetryPolicy) DoOnce(ctx context.Context, fn func() error) error {
+	return p.do(ctx, fn, isRejected)
+}
+
+func (p *RetryPolicy) do(ctx context.Context, fn func() error, retryable func(err error) bool) error {
+	if p == nil {
+		return fn()
+	}
+	rateLimitWaits := 0
+	for attempt := 1; ; {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		// go-github refuses requests by itself until the reset once it sees the rate limit exhausted
+		var rateLimitErr *github.RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			if rateLimitWaits >= maxRateLimitWaits {
+				return fmt.Errorf("gave up after waiting for the reset of rate limit %d times: %w", rateLimitWaits, err)
+			}
+			rateLimitWaits++
+			wait := rateLimitErr.Rate.Reset.Time.Add(resetMargin).Sub(p.now())
+			logging.Infof("rate limit exhausted; wait %s until reset", wait)
+			if err := p.sleep(ctx, wait); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !retryable(err) {
+			return err
+		}
+		if attempt >= p.MaxAttempts {
+			return fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+		}
+		wait := p.backoff(attempt, err)
+		logging.Warnf("attempt %d/%d failed: %v; retry after %s", attempt, p.MaxAttempts, err, wait)
+		if err := p.sleep(ctx, wait); err != nil {
+			return err
+		}
+		attempt++
+	}
+}
+
+func (p *RetryPolicy) backoff(attempt int, err error) time.Duration {
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) && abuseErr.RetryAfter != nil {
+		return *abuseErr.RetryAfter
+	}
+	d := p.BaseDelay << uint(attempt-1)
+	if d > p.MaxDelay || d <= 0 {
+		d = p.MaxDelay
+	}
+	return p.jitter(d)
+}
+
+func equalJitter(d time.Duration) time.Duration {
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + time.Duration(rand.Int63n(int64(half)))
+}
+
+// IsRetryable tells whether the error is transient (e.g. 5xx, network errors) or permanent (e.g. 422 validation failures).
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		return true
+	}
+	var rateLimitErr *github.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return true
+	}
+	var errResp *github.ErrorResponse
+	if errors.As(err, &errResp) {
+		if errResp.Response == nil {
+			return false
+		}
+		code := errResp.Response.StatusCode
+		return code >= http.StatusInternalServerError || code == http.StatusTooManyRequests
+	}
+
+	// misconfigurations such as untrusted certificates or unknown hosts never recover by retries
+	if isPermanentNetworkError(err) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// isRejected tells whether GitHub refused the request without processing it.
+func isRejected(err error) bool {
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		return true
+	}
+	var rateLimitErr *github.RateLimitError
+	return errors.As(err, &rateLimitErr)
+}
+
+func isPermanentNetworkError(err error) bool {
+	var (
+		unknownAuthorityErr x509.UnknownAuthorityError
+		certInvalidErr      x509.CertificateInvalidError
+		hostnameErr         x509.HostnameError
+		recordHeaderErr     tls.RecordHeaderError
+		dnsErr              *net.DNSError
+	)
+	switch {
+	case errors.As(err, &unknownAuthorityErr), errors.As(err, &certInvalidErr), errors.As(err, &hostnameErr), errors.As(err, &recordHeaderErr):
+		return true
+	case errors.As(err, &dnsErr):
+		return !dnsErr.Temporary() && !dnsErr.Timeout()
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+package external
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+func errorResponse(code int) error {
+	return &github.ErrorResponse{Response: &http.Response{StatusCode: code, Request: &http.Request{Method: "POST", URL: &url.URL{}}}}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "502", err: errorResponse(http.StatusBadGateway), want: true},
+		{name: "500", err: errorResponse(http.StatusInternalServerError), want: true},
+		{name: "422", err: errorResponse(http.StatusUnprocessableEntity), want: false},
+		{name: "404", err: errorResponse(http.StatusNotFound), want: false},
+		{name: "wrapped 503", err: fmt.Errorf("failed to list issues: %w", errorResponse(http.StatusServiceUnavailable)), want: true},
+		{name: "abuse", err: &github.AbuseRateLimitError{}, want: true},
+		{name: "network", err: &url.Error{Op: "Get", URL: "https://api.github.com/", Err: errors.New("connection reset by peer")}, want: true},
+		{name: "canceled", err: &url.Error{Op: "Get", URL: "https://api.github.com/", Err: context.Canceled}, want: false},
+		{name: "untrusted certificate", err: &url.Error{Op: "Get", URL: "https://ghe.example.com/", Err: x509.UnknownAuthorityError{}}, want: false},
+		{name: "hostname mismatch", err: &url.Error{Op: "Get", URL: "https://ghe.example.com/", Err: x509.HostnameError{Host: "ghe.example.com"}}, want: false},
+		{name: "unknown host", err: &url.Error{Op: "Get", URL: "https://ghe.example.com/", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "ghe.example.com"}}}, want: false},
+		{name: "DNS timeout", err: &url.Error{Op: "Get", URL: "https://ghe.example.com/", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Err: "i/o timeout", Name: "ghe.example.com", IsTimeout: true}}}, want: true},
+		{name: "unknown", err: errors.New("oops"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Do(t *testing.T) {
+	tests := []struct {
+		name      string
+		errs      []error
+		wantErr   bool
+		wantCalls int
+		wantSlept []time.Duration
+	}{
+		{
+			name:      "succeed at first",
+			errs:      []error{nil},
+			wantCalls: 1,
+			wantSlept: []time.Duration{},
+		},
+		{
+			name:      "succeed after transient failures",
+			errs:      []error{errorResponse(http.StatusBadGateway), errorResponse(http.StatusBadGateway), nil},
+			wantCalls: 3,
+			wantSlept: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:      "permanent failure",
+			errs:      []error{errorResponse(http.StatusUnprocessableEntity)},
+			wantErr:   true,
+			wantCalls: 1,
+			wantSlept: []time.Duration{},
+		},
+		{
+			name:      "give up",
+			errs:      []error{errorResponse(http.StatusBadGateway), errorResponse(http.StatusBadGateway), errorResponse(http.StatusBadGateway)},
+			wantErr:   true,
+			wantCalls: 3,
+			wantSlept: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:      "rate limit does not count as attempts",
+			errs:      []error{&github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: time.Unix(1010, 0)}}}, nil},
+			wantCalls: 2,
+			wantSlept: []time.Duration{11 * time.Second},
+		},
+		{
+			name: "give up waiting for rate limit",
+			errs: []error{
+				&github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: time.Unix(1010, 0)}}},
+				&github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: time.Unix(1010, 0)}}},
+				&github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: time.Unix(1010, 0)}}},
+				&github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: time.Unix(1010, 0)}}},
+			},
+			wantErr:   true,
+			wantCalls: 4,
+			wantSlept: []time.Duration{11 * time.Second, 11 * time.Second, 11 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slept := []time.Duration{}
+			p := NewRetryPolicy(3, time.Second, 10*time.Second)
+			p.now = func() time.Time { return time.Unix(1000, 0) }
+			p.sleep = func(ctx context.Context, d time.Duration) error {
+				slept = append(slept, d)
+				return nil
+			}
+			p.jitter = func(d time.Duration) time.Duration { return d }
+
+			calls := 0
+			err := p.Do(context.Background(), func() error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if fmt.Sprint(slept) != fmt.Sprint(tt.wantSlept) {
+				t.Errorf("slept = %v, want %v", slept, tt.wantSlept)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_DoOnce(t *testing.T) {
+	tests := []struct {
+		name      string
+		errs      []error
+		wantErr   bool
+		wantCalls int
+	}{
+		{
+			name:      "not retried on 502 since the creation may have been committed",
+			errs:      []error{errorResponse(http.StatusBadGateway)},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:      "not retried on network errors",
+			errs:      []error{&url.Error{Op: "Post", URL: "https://api.github.com/", Err: errors.New("connection reset by peer")}},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:      "retried on abuse detection",
+			errs:      []error{&github.AbuseRateLimitError{}, nil},
+			wantCalls: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewRetryPolicy(3, time.Second, 10*time.Second)
+			p.sleep = func(ctx context.Context, d time.Duration) error { return nil }
+
+			calls := 0
+			err := p.DoOnce(context.Background(), func() error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DoOnce() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package gitmirror
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/aereal/migrate-gh-repo/logging"
+)
+
+// Remote is a git repository to fetch from or push to.
+type Remote struct {
+	URL   string // e.g. https://github.com/aereal/migrate-gh-repo.git or a local path
+	Token string // sent as the password of basic authentication over HTTP(S)
+}
+
+// env returns environment variables to pass the token to git without exposing it in the command line.
+func (r *Remote) env() []string {
+	if r.Token == "" || !(strings.HasPrefix(r.URL, "https://") || strings.HasPrefix(r.URL, "http://")) {
+		return nil
+	}
+	credential := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + r.Token))
+	return []string{
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: Basic " + credential,
+	}
+}
+
+var (
+	defaultRefSpecs = []string{"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*"}
+	// GitHub refuses pushes to refs/pull/*, so heads of pull requests are pushed as pull/N branches
+	pullFetchRefSpec = "+refs/pull/*/head:refs/pull/*/head"
+	pullPushRefSpec  = "+refs/pull/*/head:refs/heads/pull/*"
+)
+
+// Mirror copies branches and tags (and optionally heads of pull requests) of the source repository to the target.
+// Refs only on the target are left as they are.
+type Mirror struct {
+	Source   *Remote
+	Target   *Remote
+	PullRefs bool
+	Git      string // path to the git command; "git" if empty
+}
+
+func (m *Mirror) Run(ctx context.Context) error {
+	dir, err := ioutil.TempDir("", "migrate-gh-repo-git")
+	if err != nil {
+		return fmt.Errorf("failed to create working directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := m.git(ctx, dir, nil, "init", "--bare", "--quiet"); err != nil {
+		return err
+	}
+
+	fetchRefSpecs := append([]string{}, defaultRefSpecs...)
+	pushRefSpecs := append([]string{}, defaultRefSpecs...)
+	if m.PullRefs {
+		fetchRefSpecs = append(fetchRefSpecs, pullFetchRefSpec)
+		pushRefSpecs = append(pushRefSpecs, pullPushRefSpec)
+	}
+
+	logging.Infof("fetch git repository from %s", m.Source.URL)
+	if err := m.git(ctx, dir, m.Source.env(), append([]string{"fetch", "--quiet", "--no-tags", m.Source.URL}, fetchRefSpecs...)...); err != nil {
+		return err
+	}
+	logging.Infof("push git repository to %s", m.Target.URL)
+	if err := m.git(ctx, dir, m.Target.env(), append([]string{"push", "--quiet", m.Target.URL}, pushRefSpecs...)...); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *Mirror) git(ctx context.Context, dir string, env []string, args ...string) error {
+	_, err := runGit(ctx, m.Git, dir, env, args...)
+	return err
+}
+
+func runGit(ctx context.Context, bin, dir string, env []string, args ...string) (string, error) {
+	if bin == "" {
+		bin = "git"
+	}
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Dir = dir
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0"), env...)
+	out := &bytes.Buffer{}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(out.String()))
+	}
+	return strings.TrimSpace(out.String()), nil
+}
@@ -0,0 +1,100 @@
+package gitmirror
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func refs(t *testing.T, dir string) []string {
+	return strings.Split(run(t, dir, "for-each-ref", "--format=%(refname)"), "\n")
+}
+
+func TestMirror_Run(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir, err := ioutil.TempDir("", "gitmirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	work := filepath.Join(dir, "work")
+	source := filepath.Join(dir, "source.git")
+	os.Mkdir(work, 0755)
+	run(t, work, "init", "--quiet")
+	run(t, work, "symbolic-ref", "HEAD", "refs/heads/master")
+	run(t, work, "commit", "--quiet", "--allow-empty", "-m", "first")
+	run(t, work, "branch", "feature")
+	run(t, work, "tag", "v1")
+	run(t, dir, "clone", "--quiet", "--bare", work, source)
+	run(t, source, "update-ref", "refs/pull/1/head", "feature")
+	run(t, source, "update-ref", "refs/pull/1/merge", "feature")
+	head := run(t, source, "rev-parse", "feature")
+
+	tests := []struct {
+		name     string
+		pullRefs bool
+		want     []string
+	}{
+		{
+			name: "branches and tags",
+			want: []string{"refs/heads/feature", "refs/heads/master", "refs/heads/only-target", "refs/tags/v1"},
+		},
+		{
+			name:     "with pull requests",
+			pullRefs: true,
+			want:     []string{"refs/heads/feature", "refs/heads/master", "refs/heads/only-target", "refs/heads/pull/1", "refs/tags/v1"},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := filepath.Join(dir, fmt.Sprintf("target%d.git", i))
+			run(t, dir, "init", "--quiet", "--bare", target)
+			run(t, dir, "--git-dir", target, "fetch", "--quiet", work, "master:only-target")
+
+			m := &Mirror{Source: &Remote{URL: source}, Target: &Remote{URL: target}, PullRefs: tt.pullRefs}
+			if err := m.Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if got := refs(t, target); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("refs = %v, want %v", got, tt.want)
+			}
+			if got := run(t, target, "rev-parse", "feature"); got != head {
+				t.Errorf("feature = %s, want %s", got, head)
+			}
+		})
+	}
+}
+
+func TestRemote_env(t *testing.T) {
+	if env := (&Remote{URL: "/path/to/repo.git", Token: "secret"}).env(); env != nil {
+		t.Errorf("env for local repository = %v", env)
+	}
+	env := (&Remote{URL: "https://github.com/aereal/migrate-gh-repo.git", Token: "secret"}).env()
+	if len(env) != 3 || strings.Contains(strings.Join(env, " "), "secret") {
+		t.Errorf("unexpected env = %v", env)
+	}
+}
@@ -0,0 +1,97 @@
+package gitmirror
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aereal/migrate-gh-repo/logging"
+)
+
+// Wiki copies pages of the source wiki to the target, rewriting markdown pages with Rewrite if given.
+// GitHub does not accept pushes to a wiki until its first page is created on the web, so the target wiki must be initialized beforehand;
+// its pages are replaced with the source ones.
+type Wiki struct {
+	Source  *Remote // e.g. https://github.com/aereal/migrate-gh-repo.wiki.git
+	Target  *Remote
+	Rewrite func(page string) string
+	Git     string // path to the git command; "git" if empty
+}
+
+var markdownExts = map[string]bool{".md": true, ".markdown": true}
+
+func (w *Wiki) Run(ctx context.Context) error {
+	dir, err := ioutil.TempDir("", "migrate-gh-repo-wiki")
+	if err != nil {
+		return fmt.Errorf("failed to create working directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	logging.Infof("clone wiki from %s", w.Source.URL)
+	if _, err := runGit(ctx, w.Git, dir, w.Source.env(), "clone", "--quiet", w.Source.URL, "."); err != nil {
+		return err
+	}
+	branch, err := runGit(ctx, w.Git, dir, nil, "symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return err
+	}
+
+	if w.Rewrite != nil {
+		rewritten, err := w.rewritePages(dir)
+		if err != nil {
+			return err
+		}
+		if rewritten > 0 {
+			logging.Infof("rewrite links in %d wiki pages", rewritten)
+			commit := []string{
+				"-c", "user.name=migrate-gh-repo", "-c", "user.email=migrate-gh-repo@users.noreply.github.com",
+				"commit", "--quiet", "--all", "--message", "Rewrite links to the migrated repository",
+			}
+			if _, err := runGit(ctx, w.Git, dir, nil, commit...); err != nil {
+				return err
+			}
+		}
+	}
+
+	logging.Infof("push wiki to %s", w.Target.URL)
+	if _, err := runGit(ctx, w.Git, dir, w.Target.env(), "push", "--quiet", "--force", w.Target.URL, "HEAD:refs/heads/"+branch); err != nil {
+		return err
+	}
+	return nil
+}
+
+// rewritePages rewrites markdown pages in the working tree and returns the number of changed pages.
+func (w *Wiki) rewritePages(dir string) (int, error) {
+	rewritten := 0
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !markdownExts[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		page := w.Rewrite(string(b))
+		if page == string(b) {
+			return nil
+		}
+		rewritten++
+		return ioutil.WriteFile(path, []byte(page), info.Mode())
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to rewrite wiki pages: %w", err)
+	}
+	return rewritten, nil
+}
@@ -0,0 +1,66 @@
+package gitmirror
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWiki_Run(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir, err := ioutil.TempDir("", "gitmirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	work := filepath.Join(dir, "work")
+	source := filepath.Join(dir, "source.wiki.git")
+	target := filepath.Join(dir, "target.wiki.git")
+	os.Mkdir(work, 0755)
+	run(t, work, "init", "--quiet")
+	run(t, work, "symbolic-ref", "HEAD", "refs/heads/master")
+	ioutil.WriteFile(filepath.Join(work, "Home.md"), []byte("see https://example.com/src/wiki/Usage\n"), 0644)
+	ioutil.WriteFile(filepath.Join(work, "Usage.md"), []byte("# Usage\n"), 0644)
+	ioutil.WriteFile(filepath.Join(work, "logo.txt"), []byte("https://example.com/src\n"), 0644)
+	run(t, work, "add", ".")
+	run(t, work, "commit", "--quiet", "-m", "first")
+	run(t, dir, "clone", "--quiet", "--bare", work, source)
+	// the wiki initialized on the web
+	run(t, dir, "init", "--quiet", "--bare", target)
+	run(t, dir, "--git-dir", target, "fetch", "--quiet", work, "master:master")
+
+	w := &Wiki{
+		Source: &Remote{URL: source},
+		Target: &Remote{URL: target},
+		Rewrite: func(page string) string {
+			return strings.Replace(page, "https://example.com/src", "https://example.com/dest", -1)
+		},
+	}
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "Home.md", want: "see https://example.com/dest/wiki/Usage"},
+		{path: "Usage.md", want: "# Usage"},
+		{path: "logo.txt", want: "https://example.com/src"},
+	}
+	for _, tt := range tests {
+		if got := run(t, target, "show", "master:"+tt.path); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+	if got := run(t, target, "rev-list", "--count", "master"); got != "2" {
+		t.Errorf("commits = %s, want 2", got)
+	}
+}
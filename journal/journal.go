@@ -0,0 +1,70 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aereal/migrate-gh-repo/jsonlines"
+)
+
+type Entry struct {
+	Key          string    `json:"key"`
+	Action       string    `json:"action"`
+	Target       string    `json:"target"`
+	TargetID     int64     `json:"targetID,omitempty"`
+	TargetNumber int       `json:"targetNumber,omitempty"`
+	TargetType   string    `json:"targetType,omitempty"`
+	CompletedAt  time.Time `json:"completedAt"`
+}
+
+// Journal is an append-only JSON-lines file that records completed requests.
+type Journal struct {
+	mu        sync.Mutex
+	f         *jsonlines.File
+	completed map[string]*Entry
+}
+
+func Open(path string) (*Journal, error) {
+	completed := map[string]*Entry{}
+	f, err := jsonlines.Open(path, func(line []byte) error {
+		entry := &Entry{}
+		if err := json.Unmarshal(line, entry); err != nil {
+			return err
+		}
+		completed[entry.Key] = entry
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal (%q): %w", path, err)
+	}
+	return &Journal{f: f, completed: completed}, nil
+}
+
+func (j *Journal) Lookup(key string) (*Entry, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e, ok := j.completed[key]
+	return e, ok
+}
+
+func (j *Journal) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.completed)
+}
+
+func (j *Journal) Record(entry *Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.f.Append(entry); err != nil {
+		return fmt.Errorf("failed to record journal entry: %w", err)
+	}
+	j.completed[entry.Key] = entry
+	return nil
+}
+
+func (j *Journal) Close() error {
+	return j.f.Close()
+}
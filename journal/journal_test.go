@@ -0,0 +1,70 @@
+package journal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal.jsonl")
+
+	j, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Record(&Entry{Key: "a", Action: "create_issue", TargetID: 1, TargetNumber: 2, CompletedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate the process killed while writing the next entry
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"key":"b","act`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	j, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	entry, ok := j.Lookup("a")
+	if !ok {
+		t.Fatal("entry a not found")
+	}
+	if entry.TargetID != 1 || entry.TargetNumber != 2 {
+		t.Errorf("entry = %#v", entry)
+	}
+	if _, ok := j.Lookup("b"); ok {
+		t.Error("truncated entry b must be ignored")
+	}
+	if err := j.Record(&Entry{Key: "b", Action: "create_label"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := j.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if _, ok := reopened.Lookup("b"); !ok {
+		t.Error("entry b not found after reopen")
+	}
+}
@@ -0,0 +1,81 @@
+// Package jsonlines provides append-only JSON-lines files recording entries one by one, e.g. the journal and the mapping.
+package jsonlines
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// File is an append-only JSON-lines file, which is synced on each entry to survive crashes.
+type File struct {
+	f *os.File
+}
+
+// Open opens the file at path and passes each complete line to decode.
+// The last line is dropped if the previous run was killed while writing it.
+func Open(path string, decode func(line []byte) error) (*File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	size, err := read(f, decode)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to truncate: %w", err)
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to seek: %w", err)
+	}
+	return &File{f: f}, nil
+}
+
+// read decodes lines and returns the size of complete lines.
+func read(r io.Reader, decode func(line []byte) error) (int64, error) {
+	br := bufio.NewReader(r)
+	var size int64
+	for lineNum := 1; ; lineNum++ {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			return size, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		size += int64(len(line))
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if err := decode(line); err != nil {
+			return 0, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+	}
+}
+
+// Append writes v as a line and syncs the file.
+func (f *File) Append(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode entry: %w", err)
+	}
+	if _, err := f.f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("failed to write entry: %w", err)
+	}
+	if err := f.f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync: %w", err)
+	}
+	return nil
+}
+
+func (f *File) Close() error {
+	return f.f.Close()
+}
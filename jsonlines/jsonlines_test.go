@@ -0,0 +1,78 @@
+package jsonlines
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type entry struct {
+	Name string `json:"name"`
+}
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonlines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "entries.jsonl")
+
+	// the previous run was killed while writing the second entry
+	if err := ioutil.WriteFile(path, []byte(`{"name":"a"}`+"\n\n"+`{"na`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	decode := func(line []byte) error {
+		e := &entry{}
+		if err := json.Unmarshal(line, e); err != nil {
+			return err
+		}
+		names = append(names, e.Name)
+		return nil
+	}
+
+	f, err := Open(path, decode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Append(&entry{Name: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	names = []string{}
+	f, err = Open(path, decode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if want := []string{"a", "b"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+}
+
+func TestOpen_broken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonlines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "entries.jsonl")
+	if err := ioutil.WriteFile(path, []byte(`{"name":"a"}`+"\n"+`broken`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Open(path, func(line []byte) error { return json.Unmarshal(line, &entry{}) })
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if got, want := err.Error(), "line 2: invalid character 'b' looking for beginning of value"; got != want {
+		t.Errorf("error = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[string]Level{
+	"debug": LevelDebug,
+	"info":  LevelInfo,
+	"warn":  LevelWarn,
+	"error": LevelError,
+}
+
+func ParseLevel(s string) (Level, error) {
+	l, ok := levelNames[strings.ToLower(s)]
+	if !ok {
+		return 0, fmt.Errorf("unknown log level: %q (debug, info, warn or error expected)", s)
+	}
+	return l, nil
+}
+
+var current = LevelInfo
+
+func SetLevel(l Level) {
+	current = l
+}
+
+func Debugf(format string, args ...interface{}) {
+	logf(LevelDebug, format, args...)
+}
+
+func Infof(format string, args ...interface{}) {
+	logf(LevelInfo, format, args...)
+}
+
+func Warnf(format string, args ...interface{}) {
+	logf(LevelWarn, "! "+format, args...)
+}
+
+func Errorf(format string, args ...interface{}) {
+	logf(LevelError, "! "+format, args...)
+}
+
+func logf(l Level, format string, args ...interface{}) {
+	if l < current {
+		return
+	}
+	log.Printf(format, args...)
+}
@@ -1,48 +1,21 @@
 package main
 
 import (
-	"context"
+	"errors"
+	"flag"
 	"log"
 	"os"
-
-	"github.com/aereal/migrate-gh-repo/config"
-	"github.com/aereal/migrate-gh-repo/domain"
-	"github.com/aereal/migrate-gh-repo/usecase"
 )
 
 func main() {
 	if err := run(os.Args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
 		log.Printf("! %v", err)
 		os.Exit(1)
 	}
 }
-
-func run(argv []string) error {
-	cfg, err := config.Load("./config/default.cue")
-	if err != nil {
-		return err
-	}
-	log.Printf("config = %#v", cfg)
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	sourceClient, err := cfg.Source.GitHubClient(ctx)
-	if err != nil {
-		return err
-	}
-	targetClient, err := cfg.Target.GitHubClient(ctx)
-	if err != nil {
-		return err
-	}
-
-	resolver := domain.NewUserAliasResolver(cfg.UserAliases)
-	u, err := usecase.New(resolver, sourceClient, targetClient, cfg.SkipUsers)
-	if err != nil {
-		return err
-	}
-	if err := u.Migrate(ctx, cfg.Source.Repo, cfg.Target.Repo); err != nil {
-		return err
-	}
-	return nil
-}
@@ -0,0 +1,184 @@
+package mapping
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/aereal/migrate-gh-repo/jsonlines"
+)
+
+type Kind string
+
+const (
+	KindMilestone     = Kind("milestone")
+	KindLabel         = Kind("label")
+	KindIssue         = Kind("issue")
+	KindComment       = Kind("comment")
+	KindReview        = Kind("review")
+	KindReviewThread  = Kind("review_thread")
+	KindProject       = Kind("project")
+	KindProjectColumn = Kind("project_column")
+	KindProjectCard   = Kind("project_card")
+	KindRelease       = Kind("release")
+	KindReleaseAsset  = Kind("release_asset")
+)
+
+// Ref identifies an entity on the source repository.
+type Ref struct {
+	Kind Kind   `json:"kind"`
+	Key  string `json:"key"`
+	ID   int64  `json:"id,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+type Entry struct {
+	Kind         Kind   `json:"kind"`
+	SourceKey    string `json:"sourceKey"`
+	SourceID     int64  `json:"sourceID,omitempty"`
+	SourceURL    string `json:"sourceURL,omitempty"`
+	TargetID     int64  `json:"targetID,omitempty"`
+	TargetNumber int    `json:"targetNumber,omitempty"`
+	TargetURL    string `json:"targetURL,omitempty"`
+	TargetType   string `json:"targetType,omitempty"` // e.g. "PullRequest" for issues migrated as pull requests; empty if same as the source
+}
+
+func NewEntry(ref *Ref, targetID int64, targetNumber int, targetURL string) *Entry {
+	return &Entry{
+		Kind:         ref.Kind,
+		SourceKey:    ref.Key,
+		SourceID:     ref.ID,
+		SourceURL:    ref.URL,
+		TargetID:     targetID,
+		TargetNumber: targetNumber,
+		TargetURL:    targetURL,
+	}
+}
+
+type entryKey struct {
+	kind Kind
+	key  string
+}
+
+// Store keeps source-to-target identities of migrated entities.
+// Entries are appended to a JSON-lines file and the last one wins for the same source entity.
+type Store struct {
+	mu      sync.Mutex
+	f       *jsonlines.File
+	entries map[entryKey]*Entry
+}
+
+func NewMemoryStore() *Store {
+	return &Store{entries: map[entryKey]*Entry{}}
+}
+
+func Open(path string) (*Store, error) {
+	s := NewMemoryStore()
+	f, err := jsonlines.Open(path, func(line []byte) error {
+		entry := &Entry{}
+		if err := json.Unmarshal(line, entry); err != nil {
+			return err
+		}
+		s.entries[entryKey{entry.Kind, entry.SourceKey}] = entry
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mapping (%q): %w", path, err)
+	}
+	s.f = f
+	return s, nil
+}
+
+func (s *Store) Put(entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := entryKey{entry.Kind, entry.SourceKey}
+	if prev, ok := s.entries[k]; ok && *prev == *entry {
+		return nil
+	}
+	s.entries[k] = entry
+	if s.f == nil {
+		return nil
+	}
+	if err := s.f.Append(entry); err != nil {
+		return fmt.Errorf("failed to put mapping entry: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) Lookup(kind Kind, sourceKey string) (*Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[entryKey{kind, sourceKey}]
+	return e, ok
+}
+
+// Entries returns all entries ordered by kind and source key.
+func (s *Store) Entries() []*Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]*Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Kind != entries[j].Kind {
+			return entries[i].Kind < entries[j].Kind
+		}
+		ni, erri := strconv.ParseInt(entries[i].SourceKey, 10, 64)
+		nj, errj := strconv.ParseInt(entries[j].SourceKey, 10, 64)
+		if erri == nil && errj == nil {
+			return ni < nj
+		}
+		return entries[i].SourceKey < entries[j].SourceKey
+	})
+	return entries
+}
+
+func (s *Store) Close() error {
+	if s.f == nil {
+		return nil
+	}
+	return s.f.Close()
+}
+
+func (s *Store) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s.Entries())
+}
+
+func (s *Store) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"kind", "source_key", "source_id", "source_url", "target_id", "target_number", "target_url", "target_type"}); err != nil {
+		return err
+	}
+	for _, e := range s.Entries() {
+		record := []string{
+			string(e.Kind),
+			e.SourceKey,
+			formatInt(e.SourceID),
+			e.SourceURL,
+			formatInt(e.TargetID),
+			formatInt(int64(e.TargetNumber)),
+			e.TargetURL,
+			e.TargetType,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatInt(n int64) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.FormatInt(n, 10)
+}
@@ -0,0 +1,103 @@
+package mapping
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mapping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mapping.jsonl")
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []*Entry{
+		NewEntry(&Ref{Kind: KindIssue, Key: "10", ID: 1010, URL: "https://github.com/aereal/src/issues/10"}, 2010, 10, "https://github.com/aereal/dest/issues/10"),
+		NewEntry(&Ref{Kind: KindIssue, Key: "9", ID: 1009}, 2009, 9, ""),
+		NewEntry(&Ref{Kind: KindIssue, Key: "11", ID: 1011}, 2011, 11, "https://github.com/aereal/dest/pull/11"),
+		NewEntry(&Ref{Kind: KindLabel, Key: "bug"}, 3001, 0, ""),
+		NewEntry(&Ref{Kind: KindLabel, Key: "bug"}, 3002, 0, ""),
+	}
+	entries[2].TargetType = "PullRequest"
+	for _, e := range entries {
+		if err := s.Put(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	got, ok := s.Lookup(KindLabel, "bug")
+	if !ok || got.TargetID != 3002 {
+		t.Errorf("Lookup(label, bug) = %#v, %v", got, ok)
+	}
+	if _, ok := s.Lookup(KindIssue, "bug"); ok {
+		t.Error("Lookup(issue, bug) must not be found")
+	}
+
+	buf := &bytes.Buffer{}
+	if err := s.WriteCSV(buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `kind,source_key,source_id,source_url,target_id,target_number,target_url,target_type
+issue,9,1009,,2009,9,,
+issue,10,1010,https://github.com/aereal/src/issues/10,2010,10,https://github.com/aereal/dest/issues/10,
+issue,11,1011,,2011,11,https://github.com/aereal/dest/pull/11,PullRequest
+label,bug,,,3002,,,
+`
+	if buf.String() != want {
+		t.Errorf("WriteCSV() = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestStore_tornWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mapping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mapping.jsonl")
+
+	// the previous run was killed while writing the second entry
+	content := `{"kind":"label","sourceKey":"bug","targetID":3001}` + "\n" + `{"kind":"label","sourceK`
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(NewEntry(&Ref{Kind: KindLabel, Key: "feature"}, 3002, 0, "")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for key, wantID := range map[string]int64{"bug": 3001, "feature": 3002} {
+		if got, ok := s.Lookup(KindLabel, key); !ok || got.TargetID != wantID {
+			t.Errorf("Lookup(label, %s) = %#v, %v", key, got, ok)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aereal/migrate-gh-repo/config"
+	"github.com/google/go-github/github"
+)
+
+type Snapshot struct {
+	Repository string              `json:"repository"`
+	Milestones []*github.Milestone `json:"milestones"`
+	Labels     []*github.Label     `json:"labels"`
+	Issues     []*github.Issue     `json:"issues"`
+	Projects   []*github.Project   `json:"projects"`
+}
+
+func (u *Usecase) Export(ctx context.Context, source *config.Repository) (*Snapshot, error) {
+	if source == nil {
+		return nil, fmt.Errorf("source repository must be given")
+	}
+
+	milestones, err := u.sourceService.SlurpMilestones(ctx, source.Owner, source.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch milestones from source repository: %w", err)
+	}
+	labels, err := u.sourceService.SlurpLabels(ctx, source.Owner, source.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch labels from source repository: %w", err)
+	}
+	issues, err := u.sourceService.SlurpIssues(ctx, source.Owner, source.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch issues from source repository: %w", err)
+	}
+	projects, err := u.sourceService.SlurpProjects(ctx, source.Owner, source.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch projects from source repository: %w", err)
+	}
+	return &Snapshot{
+		Repository: fmt.Sprintf("%s/%s", source.Owner, source.Name),
+		Milestones: milestones,
+		Labels:     labels,
+		Issues:     issues,
+		Projects:   projects,
+	}, nil
+}
@@ -3,11 +3,14 @@ package usecase
 import (
 	"context"
 	"fmt"
-	"log"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/aereal/migrate-gh-repo/config"
 	"github.com/aereal/migrate-gh-repo/domain"
+	"github.com/aereal/migrate-gh-repo/logging"
+	"github.com/aereal/migrate-gh-repo/mapping"
 	"github.com/google/go-github/github"
 )
 
@@ -21,34 +24,180 @@ func (u *Usecase) buildIssueRequests(ctx context.Context, source, target *config
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch issues from target repository: %w", err)
 	}
-	for _, issue := range targetIssues {
-		u.issueNumberIDMapping[issue.GetNumber()] = issue.GetID()
+	matched := domain.MatchIssues(sourceIssues, targetIssues)
+	for _, issue := range sourceIssues {
+		if t, ok := matched[issue.GetNumber()]; ok {
+			if err := u.mappings.Put(mapping.NewEntry(issueRef(issue), t.GetID(), t.GetNumber(), t.GetHTMLURL())); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sourceNumbers := issueNumbers(sourceIssues)
+	u.references = u.newReferenceRewriter(source, target, sourceNumbers)
+	// numbers of issues created in this run are not known until they are created, so references to them are resolved on apply
+	u.bodyReferences = u.references.Deferring(func(n int) bool { return sourceNumbers[n] })
+
+	if u.preserveIssueNumbers {
+		sort.SliceStable(sourceIssues, func(i, j int) bool { return sourceIssues[i].GetNumber() < sourceIssues[j].GetNumber() })
 	}
 
 	reqs := []request{}
 	ops := domain.NewIssueOpsList(sourceIssues, targetIssues)
+	var gaps []int
+	if u.preserveIssueNumbers {
+		gaps, err = domain.IssueNumberGaps(targetIssues, ops)
+		if err != nil {
+			return nil, fmt.Errorf("%w; set preserveIssueNumbers to false to migrate issues with new numbers", err)
+		}
+	}
+	created := map[int]bool{}
+	bodyReqs := []request{}
 	for _, op := range ops {
-		reqs = append(reqs, newIssueRequests(u.userAliasResolver, source, target, u.skipUsers, op)...)
+		if op.Kind == domain.OpCreate {
+			for len(gaps) > 0 && gaps[0] < op.Issue.GetNumber() {
+				reqs = append(reqs, newPlaceholderIssueRequests(source, target, gaps[0])...)
+				gaps = gaps[1:]
+			}
+		}
+		issueReqs, err := u.newIssueRequests(ctx, source, target, op)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, issueReqs...)
+		if op.Kind == domain.OpCreate && len(issueReqs) > 0 {
+			if r := newUpdateIssueBodyRequest(issueReqs[0], op.Issue.GetNumber(), created); r != nil {
+				bodyReqs = append(bodyReqs, r)
+			}
+			created[op.Issue.GetNumber()] = true
+		}
+	}
+	// bodies referring to issues created after them are resolved again once all issues are created
+	reqs = append(reqs, bodyReqs...)
+
+	if u.commentHeader != nil {
+		commentReqs, err := u.buildIssueCommentRequests(ctx, source, target, sourceIssues, matched)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, commentReqs...)
 	}
 	return reqs, nil
 }
 
-func newIssueRequests(resolver *domain.UserAliasResolver, sourceRepo, targetRepo *config.Repository, skipUsers []string, op *domain.IssueOp) []request {
+func issueNumbers(issues []*github.Issue) map[int]bool {
+	numbers := map[int]bool{}
+	for _, issue := range issues {
+		numbers[issue.GetNumber()] = true
+	}
+	return numbers
+}
+
+// newReferenceRewriter resolves references to source issues with the mapping.
+// Issues to be created in this run are resolved only if they keep their numbers.
+func (u *Usecase) newReferenceRewriter(source, target *config.Repository, sourceNumbers map[int]bool) *domain.ReferenceRewriter {
+	return domain.NewReferenceRewriter(
+		&domain.RepositoryLocation{WebURL: u.sourceWebURL, Owner: source.Owner, Name: source.Name},
+		&domain.RepositoryLocation{WebURL: u.targetWebURL, Owner: target.Owner, Name: target.Name},
+		func(n int) (int, bool) {
+			if number, ok := u.lookupIssueNumber(n); ok {
+				return number, true
+			}
+			if u.preserveIssueNumbers && sourceNumbers[n] {
+				return n, true
+			}
+			return 0, false
+		},
+	)
+}
+
+// newPendingReferenceResolver resolves references left pending on planning with the mapping filled by preceding steps.
+func (u *Usecase) newPendingReferenceResolver(plan *Plan) *domain.ReferenceRewriter {
+	return domain.NewReferenceRewriter(
+		newRepositoryLocation(u.sourceWebURL, plan.Source),
+		newRepositoryLocation(u.targetWebURL, plan.Target),
+		u.lookupIssueNumber,
+	)
+}
+
+func newRepositoryLocation(webURL, fullName string) *domain.RepositoryLocation {
+	loc := &domain.RepositoryLocation{WebURL: webURL, Owner: fullName}
+	if i := strings.Index(fullName, "/"); i >= 0 {
+		loc.Owner, loc.Name = fullName[:i], fullName[i+1:]
+	}
+	return loc
+}
+
+func (u *Usecase) lookupIssueNumber(sourceNumber int) (int, bool) {
+	entry, ok := u.mappings.Lookup(mapping.KindIssue, strconv.Itoa(sourceNumber))
+	if !ok || entry.TargetNumber == 0 {
+		return 0, false
+	}
+	return entry.TargetNumber, true
+}
+
+// rewriteBody translates references and mentions in the body copied from the source.
+// References to issues created in this run are left as placeholders, which are resolved on apply.
+func (u *Usecase) rewriteBody(body string) string {
+	return u.mentions.Rewrite(u.bodyReferences.Rewrite(body))
+}
+
+// referring is implemented by requests whose bodies may have references left pending on planning.
+type referring interface {
+	resolveReferences(references *domain.ReferenceRewriter)
+}
+
+// newPlaceholderIssueRequests fills the number of an issue deleted or transferred on the source repository.
+func newPlaceholderIssueRequests(sourceRepo, targetRepo *config.Repository, number int) []request {
+	title := fmt.Sprintf("Placeholder for %s/%s#%d", sourceRepo.Owner, sourceRepo.Name, number)
+	body := fmt.Sprintf("This issue is a placeholder to keep issue numbers same as the previous repository (%s/%s), where #%d has been deleted or transferred.", sourceRepo.Owner, sourceRepo.Name, number)
+	closed := "closed"
+	return []request{
+		&createIssueRequest{
+			Owner:          targetRepo.Owner,
+			Repo:           targetRepo.Name,
+			IssueReq:       &github.IssueRequest{Title: &title, Body: &body},
+			ExpectedNumber: number,
+		},
+		&updateIssueRequest{
+			Owner:       targetRepo.Owner,
+			Repo:        targetRepo.Name,
+			IssueNumber: number,
+			IssueReq:    &github.IssueRequest{State: &closed},
+		},
+	}
+}
+
+// newIssueBody builds the body of the migrated issue, which embeds the marker to be matched with the source issue on later runs.
+func (u *Usecase) newIssueBody(sourceRepo *config.Repository, issue *github.Issue) (string, error) {
+	if u.issueBodyHeader == nil {
+		body := fmt.Sprintf("This issue or P-R imported from %s in previous repository (%s/%s)", issue.GetHTMLURL(), sourceRepo.Owner, sourceRepo.Name)
+		return domain.EmbedIssueMarker(body, issue), nil
+	}
+	attribution := domain.NewAttribution(issue.GetUser().GetLogin(), issue.GetCreatedAt(), issue.GetHTMLURL())
+	body, err := u.issueBodyHeader.Render(attribution, u.rewriteBody(issue.GetBody()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build body of issue #%d: %w", issue.GetNumber(), err)
+	}
+	return domain.EmbedIssueMarker(body, issue), nil
+}
+
+func (u *Usecase) newIssueRequests(ctx context.Context, sourceRepo, targetRepo *config.Repository, op *domain.IssueOp) ([]request, error) {
 	switch op.Kind {
 	case domain.OpCreate:
-		body := fmt.Sprintf("This issue or P-R imported from %s in previous repository (%s/%s)", op.Issue.GetHTMLURL(), sourceRepo.Owner, sourceRepo.Name)
+		body, err := u.newIssueBody(sourceRepo, op.Issue)
+		if err != nil {
+			return nil, err
+		}
 		assignees := []string{}
-		for _, u := range op.Issue.Assignees {
-			if contains(skipUsers, u.GetLogin()) {
+		for _, assignee := range op.Issue.Assignees {
+			if contains(u.skipUsers, assignee.GetLogin()) {
 				continue
 			}
-			userOnTarget, _ := resolver.AssumeResolved(u.GetLogin())
+			userOnTarget, _ := u.userAliasResolver.AssumeResolved(assignee.GetLogin())
 			assignees = append(assignees, userOnTarget)
 		}
-		labels := []string{}
-		for _, label := range op.Issue.Labels {
-			labels = append(labels, label.GetName())
-		}
+		labels := u.labelMapper.MapNames(op.Issue.Labels)
 		issueReq := &github.IssueRequest{
 			Body:      &body,
 			Assignees: &assignees,
@@ -56,107 +205,330 @@ func newIssueRequests(resolver *domain.UserAliasResolver, sourceRepo, targetRepo
 			Title:     op.Issue.Title,
 			State:     op.Issue.State,
 		}
-		if op.Issue.Milestone != nil && op.Issue.Milestone.Number != nil {
-			issueReq.Milestone = op.Issue.Milestone.Number
+		createReq := &createIssueRequest{
+			origin:   origin{Source: issueRef(op.Issue)},
+			Owner:    targetRepo.Owner,
+			Repo:     targetRepo.Name,
+			IssueReq: issueReq,
+		}
+		// milestones are matched by titles since numbers differ between repositories
+		if title := op.Issue.GetMilestone().GetTitle(); title != "" {
+			if entry, ok := u.mappings.Lookup(mapping.KindMilestone, title); ok && entry.TargetNumber != 0 {
+				number := entry.TargetNumber
+				issueReq.Milestone = &number
+			} else {
+				createReq.SourceMilestone = title
+			}
+		}
+		if u.preserveIssueNumbers {
+			createReq.ExpectedNumber = op.Issue.GetNumber()
 		}
-		reqs := []request{&createIssueRequest{
-			owner:    targetRepo.Owner,
-			repo:     targetRepo.Name,
-			issueReq: issueReq,
-		}}
+		if u.recreatePullRequests && op.Issue.IsPullRequest() && op.Issue.GetState() == "open" {
+			return u.newPullRequestRequests(ctx, sourceRepo, targetRepo, op.Issue, createReq)
+		}
+		reqs := []request{createReq}
 		if op.Issue.GetState() == "closed" {
 			reqs = append(reqs, &updateIssueRequest{
-				owner:       targetRepo.Owner,
-				repo:        targetRepo.Name,
-				issueNumber: op.Issue.GetNumber(),
-				issueReq: &github.IssueRequest{
+				Owner:             targetRepo.Owner,
+				Repo:              targetRepo.Name,
+				IssueNumber:       createReq.ExpectedNumber,
+				SourceIssueNumber: op.Issue.GetNumber(),
+				IssueReq: &github.IssueRequest{
 					State: op.Issue.State,
 				},
 			})
 		}
-		return reqs
+		return reqs, nil
 	case domain.OpUpdate:
-		log.Printf("update issue")
+		if op.Migrated() {
+			logging.Debugf("sync state of issue #%d", op.TargetIssue.GetNumber())
+			return []request{&updateIssueRequest{
+				Owner:       targetRepo.Owner,
+				Repo:        targetRepo.Name,
+				IssueNumber: op.TargetIssue.GetNumber(),
+				IssueReq: &github.IssueRequest{
+					State: op.Issue.State,
+				},
+			}}, nil
+		}
+		logging.Debugf("update issue")
 		body := fmt.Sprintf("This issue or P-R referenced as %s in previous repository (%s/%s)", op.Issue.GetHTMLURL(), sourceRepo.Owner, sourceRepo.Name)
-		labels := []string{"migrated"}
+		labels := append([]string{domain.MigratedLabel}, u.labelMapper.MapNames(op.Issue.Labels)...)
 		assignees := []string{}
-		for _, u := range op.Issue.Assignees {
-			if contains(skipUsers, u.GetLogin()) {
+		for _, assignee := range op.Issue.Assignees {
+			if contains(u.skipUsers, assignee.GetLogin()) {
 				continue
 			}
-			userOnTarget, _ := resolver.AssumeResolved(u.GetLogin())
+			userOnTarget, _ := u.userAliasResolver.AssumeResolved(assignee.GetLogin())
 			assignees = append(assignees, userOnTarget)
 		}
-		for _, l := range op.Issue.Labels {
-			labels = append(labels, l.GetName())
-		}
 		reqs := []request{
 			&createIssueCommentRequest{
-				owner:       targetRepo.Owner,
-				repo:        targetRepo.Name,
-				issueNumber: op.Issue.GetNumber(),
-				body:        body,
+				Owner:       targetRepo.Owner,
+				Repo:        targetRepo.Name,
+				IssueNumber: op.TargetIssue.GetNumber(),
+				Body:        body,
 			},
 			&updateIssueRequest{
-				owner:       targetRepo.Owner,
-				repo:        targetRepo.Name,
-				issueNumber: op.Issue.GetNumber(),
-				issueReq: &github.IssueRequest{
+				Owner:       targetRepo.Owner,
+				Repo:        targetRepo.Name,
+				IssueNumber: op.TargetIssue.GetNumber(),
+				IssueReq: &github.IssueRequest{
 					Labels:    &labels,
 					Assignees: &assignees,
 				},
 			},
 		}
-		return reqs
+		return reqs, nil
 	default:
-		return nil
+		return nil, nil
 	}
 }
 
 type createIssueRequest struct {
-	owner    string
-	repo     string
-	issueReq *github.IssueRequest
+	origin
+	Owner           string               `json:"owner"`
+	Repo            string               `json:"repo"`
+	IssueReq        *github.IssueRequest `json:"issue"`
+	ExpectedNumber  int                  `json:"expectedNumber,omitempty"`
+	SourceMilestone string               `json:"sourceMilestone,omitempty"` // resolved to the milestone number on apply if the milestone has not been created yet
 }
 
-func (r *createIssueRequest) Do(ctx context.Context, ghClient *github.Client) error {
-	log.Printf(
-		"create issue on %s/%s: title=%q body=%q labels=[%s] assignees=[%s] state=%q milestone.id=%d",
-		r.owner, r.repo,
-		r.issueReq.GetTitle(),
-		r.issueReq.GetBody(),
-		strings.Join(r.issueReq.GetLabels(), ", "),
-		strings.Join(r.issueReq.GetAssignees(), ", "),
-		r.issueReq.GetState(),
-		r.issueReq.GetMilestone(),
-	)
-	_, _, err := ghClient.Issues.Create(ctx, r.owner, r.repo, r.issueReq)
+func (r *createIssueRequest) resolve(store *mapping.Store) error {
+	return resolveMilestone(store, r.IssueReq, r.SourceMilestone)
+}
+
+func (r *createIssueRequest) resolveReferences(references *domain.ReferenceRewriter) {
+	resolveBody(references, r.IssueReq)
+}
+
+func resolveBody(references *domain.ReferenceRewriter, issueReq *github.IssueRequest) {
+	if issueReq.Body != nil {
+		body := references.ResolvePending(issueReq.GetBody())
+		issueReq.Body = &body
+	}
+}
+
+// resolveMilestone sets the number of the milestone on the target migrated from the source one titled title.
+func resolveMilestone(store *mapping.Store, issueReq *github.IssueRequest, title string) error {
+	if title == "" || issueReq.Milestone != nil {
+		return nil
+	}
+	entry, err := lookupMapping(store, mapping.KindMilestone, title)
 	if err != nil {
 		return err
 	}
+	number := entry.TargetNumber
+	issueReq.Milestone = &number
 	return nil
 }
 
+func describeMilestone(issueReq *github.IssueRequest, sourceMilestone string) string {
+	if issueReq.Milestone == nil && sourceMilestone != "" {
+		return fmt.Sprintf("milestone=%q", sourceMilestone)
+	}
+	return fmt.Sprintf("milestone=%d", issueReq.GetMilestone())
+}
+
+func (r *createIssueRequest) Do(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	logging.Infof(
+		"create issue on %s/%s: title=%q body=%q labels=[%s] assignees=[%s] state=%q milestone.id=%d",
+		r.Owner, r.Repo,
+		r.IssueReq.GetTitle(),
+		r.IssueReq.GetBody(),
+		strings.Join(r.IssueReq.GetLabels(), ", "),
+		strings.Join(r.IssueReq.GetAssignees(), ", "),
+		r.IssueReq.GetState(),
+		r.IssueReq.GetMilestone(),
+	)
+	issue, _, err := ghClient.Issues.Create(ctx, r.Owner, r.Repo, r.IssueReq)
+	if err != nil {
+		return nil, err
+	}
+	if r.ExpectedNumber != 0 && issue.GetNumber() != r.ExpectedNumber {
+		return nil, fmt.Errorf("issue created as #%d but #%d expected; issue numbers no longer match the source repository", issue.GetNumber(), r.ExpectedNumber)
+	}
+	return &outcome{id: issue.GetID(), number: issue.GetNumber(), url: issue.GetHTMLURL()}, nil
+}
+
+func (r *createIssueRequest) findCreated(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	issue, err := findIssueByMarker(ctx, ghClient, r.Owner, r.Repo, r.IssueReq.GetBody())
+	if err != nil || issue == nil {
+		return nil, err
+	}
+	if r.ExpectedNumber != 0 && issue.GetNumber() != r.ExpectedNumber {
+		return nil, fmt.Errorf("issue created as #%d but #%d expected; issue numbers no longer match the source repository", issue.GetNumber(), r.ExpectedNumber)
+	}
+	return &outcome{id: issue.GetID(), number: issue.GetNumber(), url: issue.GetHTMLURL()}, nil
+}
+
+// findIssueByMarker returns the issue or pull request recently created with the same marker as the body, or nil if not found.
+func findIssueByMarker(ctx context.Context, ghClient *github.Client, owner, repo, body string) (*github.Issue, error) {
+	marker, ok := domain.LastMarker(body)
+	if !ok {
+		return nil, fmt.Errorf("cannot tell whether the issue has been created on %s/%s since it has no marker", owner, repo)
+	}
+	// the issue created by the failed attempt must be one of the latest
+	opts := &github.IssueListByRepoOptions{State: "all", Sort: "created", Direction: "desc", ListOptions: github.ListOptions{PerPage: 100}}
+	issues, _, err := ghClient.Issues.ListByRepo(ctx, owner, repo, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issues on %s/%s: %w", owner, repo, err)
+	}
+	for _, issue := range issues {
+		if strings.Contains(issue.GetBody(), marker) {
+			logging.Infof("issue with %s has been created as %s/%s#%d", marker, owner, repo, issue.GetNumber())
+			return issue, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *createIssueRequest) describe() *PlanStep {
+	target := fmt.Sprintf("%s/%s", r.Owner, r.Repo)
+	if r.ExpectedNumber != 0 {
+		target = fmt.Sprintf("%s#%d", target, r.ExpectedNumber)
+	}
+	return &PlanStep{
+		Action: actionCreateIssue,
+		Target: target,
+		Summary: fmt.Sprintf(
+			"title=%q labels=[%s] assignees=[%s] state=%q %s",
+			r.IssueReq.GetTitle(),
+			strings.Join(r.IssueReq.GetLabels(), ", "),
+			strings.Join(r.IssueReq.GetAssignees(), ", "),
+			r.IssueReq.GetState(),
+			describeMilestone(r.IssueReq, r.SourceMilestone),
+		),
+	}
+}
+
 type updateIssueRequest struct {
-	owner       string
-	repo        string
-	issueNumber int
-	issueReq    *github.IssueRequest
+	Owner             string               `json:"owner"`
+	Repo              string               `json:"repo"`
+	IssueNumber       int                  `json:"issueNumber"`
+	SourceIssueNumber int                  `json:"sourceIssueNumber,omitempty"` // resolved to IssueNumber on apply if the number is not known on planning
+	IssueReq          *github.IssueRequest `json:"issue"`
+	SourceMilestone   string               `json:"sourceMilestone,omitempty"` // resolved to the milestone number on apply if the milestone has not been created yet
+}
+
+func (r *updateIssueRequest) resolve(store *mapping.Store) error {
+	if r.IssueNumber == 0 {
+		entry, err := lookupMapping(store, mapping.KindIssue, strconv.Itoa(r.SourceIssueNumber))
+		if err != nil {
+			return err
+		}
+		r.IssueNumber = entry.TargetNumber
+	}
+	return resolveMilestone(store, r.IssueReq, r.SourceMilestone)
+}
+
+func (r *updateIssueRequest) resolveReferences(references *domain.ReferenceRewriter) {
+	resolveBody(references, r.IssueReq)
 }
 
-func (r *updateIssueRequest) Do(ctx context.Context, ghClinet *github.Client) error {
-	log.Printf(
+func (r *updateIssueRequest) Do(ctx context.Context, ghClinet *github.Client) (*outcome, error) {
+	logging.Infof(
 		"update issue on %s/%s#%d: title=%q body=%q labels=[%s] assignees=[%s] state=%q milestone.id=%d",
-		r.owner, r.repo, r.issueNumber,
-		r.issueReq.GetTitle(),
-		r.issueReq.GetBody(),
-		strings.Join(r.issueReq.GetLabels(), ", "),
-		strings.Join(r.issueReq.GetAssignees(), ", "),
-		r.issueReq.GetState(),
-		r.issueReq.GetMilestone(),
+		r.Owner, r.Repo, r.IssueNumber,
+		r.IssueReq.GetTitle(),
+		r.IssueReq.GetBody(),
+		strings.Join(r.IssueReq.GetLabels(), ", "),
+		strings.Join(r.IssueReq.GetAssignees(), ", "),
+		r.IssueReq.GetState(),
+		r.IssueReq.GetMilestone(),
 	)
-	if _, _, err := ghClinet.Issues.Edit(ctx, r.owner, r.repo, r.issueNumber, r.issueReq); err != nil {
+	issue, _, err := ghClinet.Issues.Edit(ctx, r.Owner, r.Repo, r.IssueNumber, r.IssueReq)
+	if err != nil {
+		return nil, err
+	}
+	return &outcome{id: issue.GetID(), number: issue.GetNumber()}, nil
+}
+
+func (r *updateIssueRequest) idempotent() {}
+
+func (r *updateIssueRequest) describe() *PlanStep {
+	target := fmt.Sprintf("%s/%s#%d", r.Owner, r.Repo, r.IssueNumber)
+	if r.IssueNumber == 0 {
+		target = fmt.Sprintf("%s/%s (migrated from #%d)", r.Owner, r.Repo, r.SourceIssueNumber)
+	}
+	return &PlanStep{
+		Action: actionUpdateIssue,
+		Target: target,
+		Summary: fmt.Sprintf(
+			"labels=[%s] assignees=[%s] state=%q",
+			strings.Join(r.IssueReq.GetLabels(), ", "),
+			strings.Join(r.IssueReq.GetAssignees(), ", "),
+			r.IssueReq.GetState(),
+		),
+	}
+}
+
+// newUpdateIssueBodyRequest returns the request to set the body of the issue created by created again
+// if it refers issues not created before it, or nil otherwise.
+func newUpdateIssueBodyRequest(created request, sourceNumber int, createdBefore map[int]bool) *updateIssueBodyRequest {
+	r := &updateIssueBodyRequest{SourceIssueNumber: sourceNumber}
+	switch c := created.(type) {
+	case *createIssueRequest:
+		r.Owner, r.Repo, r.IssueNumber, r.Body = c.Owner, c.Repo, c.ExpectedNumber, c.IssueReq.GetBody()
+	case *createPullRequestRequest:
+		r.Owner, r.Repo, r.IssueNumber, r.Body = c.Owner, c.Repo, c.ExpectedNumber, c.Fallback.IssueReq.GetBody()
+		r.PullRequestBody = c.PullRequest.GetBody()
+	default:
+		return nil
+	}
+	for _, n := range domain.PendingReferences(r.Body) {
+		if !createdBefore[n] {
+			return r
+		}
+	}
+	return nil
+}
+
+// updateIssueBodyRequest sets the body of the migrated issue again to resolve references to issues created after it.
+type updateIssueBodyRequest struct {
+	Owner             string `json:"owner"`
+	Repo              string `json:"repo"`
+	IssueNumber       int    `json:"issueNumber"`
+	SourceIssueNumber int    `json:"sourceIssueNumber"`
+	Body              string `json:"body"`
+	PullRequestBody   string `json:"pullRequestBody,omitempty"` // used instead of Body if the issue has been created as a pull request
+}
+
+func (r *updateIssueBodyRequest) resolve(store *mapping.Store) error {
+	entry, err := lookupMapping(store, mapping.KindIssue, strconv.Itoa(r.SourceIssueNumber))
+	if err != nil {
 		return err
 	}
+	r.IssueNumber = entry.TargetNumber
+	if entry.TargetType == contentTypePullRequest && r.PullRequestBody != "" {
+		r.Body = r.PullRequestBody
+	}
 	return nil
 }
+
+func (r *updateIssueBodyRequest) resolveReferences(references *domain.ReferenceRewriter) {
+	r.Body = references.ResolvePending(r.Body)
+}
+
+func (r *updateIssueBodyRequest) Do(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	logging.Infof("update body of issue on %s/%s#%d: body=%q", r.Owner, r.Repo, r.IssueNumber, r.Body)
+	issue, _, err := ghClient.Issues.Edit(ctx, r.Owner, r.Repo, r.IssueNumber, &github.IssueRequest{Body: &r.Body})
+	if err != nil {
+		return nil, err
+	}
+	return &outcome{id: issue.GetID(), number: issue.GetNumber()}, nil
+}
+
+func (r *updateIssueBodyRequest) idempotent() {}
+
+func (r *updateIssueBodyRequest) describe() *PlanStep {
+	target := fmt.Sprintf("%s/%s#%d", r.Owner, r.Repo, r.IssueNumber)
+	if r.IssueNumber == 0 {
+		target = fmt.Sprintf("%s/%s (migrated from #%d)", r.Owner, r.Repo, r.SourceIssueNumber)
+	}
+	return &PlanStep{
+		Action:  actionUpdateIssueBody,
+		Target:  target,
+		Summary: fmt.Sprintf("body=%q", r.Body),
+	}
+}
@@ -2,24 +2,167 @@ package usecase
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/aereal/migrate-gh-repo/config"
+	"github.com/aereal/migrate-gh-repo/domain"
+	"github.com/aereal/migrate-gh-repo/logging"
+	"github.com/aereal/migrate-gh-repo/mapping"
 	"github.com/google/go-github/github"
 )
 
+// buildIssueCommentRequests builds requests to create comments on target issues matched with source issues by their numbers in matched.
+func (u *Usecase) buildIssueCommentRequests(ctx context.Context, source, target *config.Repository, sourceIssues []*github.Issue, matched map[int]*github.Issue) ([]request, error) {
+	reqs := []request{}
+	for _, issue := range sourceIssues {
+		withReviews := u.migrateReviews && issue.IsPullRequest()
+		if issue.GetComments() == 0 && !withReviews {
+			continue
+		}
+		sourceComments, err := u.sourceService.SlurpIssueComments(ctx, source.Owner, source.Name, issue.GetNumber())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch comments of #%d from source repository: %w", issue.GetNumber(), err)
+		}
+		// the issue to be created is resolved by the mapping on apply unless it keeps the number
+		targetNumber := 0
+		if u.preserveIssueNumbers {
+			targetNumber = issue.GetNumber()
+		}
+		targetComments := []*github.IssueComment{}
+		if t, ok := matched[issue.GetNumber()]; ok {
+			targetNumber = t.GetNumber()
+			targetComments, err = u.targetService.SlurpIssueComments(ctx, target.Owner, target.Name, targetNumber)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch comments of #%d from target repository: %w", targetNumber, err)
+			}
+		}
+
+		targetByMarker := map[string]*github.IssueComment{}
+		for _, c := range targetComments {
+			if id, ok := domain.CommentMarkerSourceID(c); ok {
+				targetByMarker[id] = c
+			}
+		}
+		for _, c := range sourceComments {
+			ref := issueCommentRef(c)
+			if t, ok := targetByMarker[ref.Key]; ok {
+				if err := u.mappings.Put(mapping.NewEntry(ref, t.GetID(), 0, t.GetHTMLURL())); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		commentReqs := []*timedRequest{}
+		for _, op := range domain.NewIssueCommentOpsList(sourceComments, targetComments) {
+			if op.Kind != domain.OpCreate {
+				continue
+			}
+			attribution := domain.NewAttribution(op.IssueComment.GetUser().GetLogin(), op.IssueComment.GetCreatedAt(), op.IssueComment.GetHTMLURL())
+			body, err := u.commentHeader.Render(attribution, u.rewriteBody(op.IssueComment.GetBody()))
+			if err != nil {
+				return nil, fmt.Errorf("failed to build comment (id=%d) on #%d: %w", op.IssueComment.GetID(), issue.GetNumber(), err)
+			}
+			commentReqs = append(commentReqs, &timedRequest{
+				at: op.IssueComment.GetCreatedAt(),
+				request: &createIssueCommentRequest{
+					origin:            origin{Source: issueCommentRef(op.IssueComment)},
+					Owner:             target.Owner,
+					Repo:              target.Name,
+					IssueNumber:       targetNumber,
+					SourceIssueNumber: issue.GetNumber(),
+					Body:              domain.EmbedCommentMarker(body, op.IssueComment),
+				},
+			})
+		}
+		if withReviews {
+			reviewReqs, err := u.buildReviewRequests(ctx, source, target, issue, targetNumber, targetComments)
+			if err != nil {
+				return nil, err
+			}
+			commentReqs = append(commentReqs, reviewReqs...)
+		}
+
+		sort.SliceStable(commentReqs, func(i, j int) bool { return commentReqs[i].at.Before(commentReqs[j].at) })
+		for _, r := range commentReqs {
+			reqs = append(reqs, r.request)
+		}
+	}
+	return reqs, nil
+}
+
+// timedRequest is the request to create a comment written at the time, to be sorted in order of the discussion.
+type timedRequest struct {
+	at time.Time
+	request
+}
+
 type createIssueCommentRequest struct {
-	owner       string
-	repo        string
-	issueNumber int
-	body        string
+	origin
+	Owner             string `json:"owner"`
+	Repo              string `json:"repo"`
+	IssueNumber       int    `json:"issueNumber"`
+	SourceIssueNumber int    `json:"sourceIssueNumber,omitempty"` // resolved to IssueNumber on apply if the number is not known on planning
+	Body              string `json:"body"`
 }
 
-func (r *createIssueCommentRequest) Do(ctx context.Context, ghClient *github.Client) error {
-	issueComment := &github.IssueComment{Body: &r.body}
-	log.Printf("create issue comment on %s/%s#%d issueComment=%s", r.owner, r.repo, r.issueNumber, issueComment)
-	_, _, err := ghClient.Issues.CreateComment(ctx, r.owner, r.repo, r.issueNumber, issueComment)
+func (r *createIssueCommentRequest) resolve(store *mapping.Store) error {
+	if r.IssueNumber != 0 {
+		return nil
+	}
+	entry, err := lookupMapping(store, mapping.KindIssue, strconv.Itoa(r.SourceIssueNumber))
 	if err != nil {
 		return err
 	}
+	r.IssueNumber = entry.TargetNumber
 	return nil
 }
+
+func (r *createIssueCommentRequest) resolveReferences(references *domain.ReferenceRewriter) {
+	r.Body = references.ResolvePending(r.Body)
+}
+
+func (r *createIssueCommentRequest) Do(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	issueComment := &github.IssueComment{Body: &r.Body}
+	logging.Infof("create issue comment on %s/%s#%d issueComment=%s", r.Owner, r.Repo, r.IssueNumber, issueComment)
+	created, _, err := ghClient.Issues.CreateComment(ctx, r.Owner, r.Repo, r.IssueNumber, issueComment)
+	if err != nil {
+		return nil, err
+	}
+	return &outcome{id: created.GetID(), url: created.GetHTMLURL()}, nil
+}
+
+func (r *createIssueCommentRequest) findCreated(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	marker, ok := domain.LastMarker(r.Body)
+	if !ok {
+		return nil, fmt.Errorf("cannot tell whether the comment has been created on %s/%s#%d since it has no marker", r.Owner, r.Repo, r.IssueNumber)
+	}
+	// the comment created by the failed attempt must be one of the latest
+	opts := &github.IssueListCommentsOptions{Sort: "created", Direction: "desc", ListOptions: github.ListOptions{PerPage: 100}}
+	comments, _, err := ghClient.Issues.ListComments(ctx, r.Owner, r.Repo, r.IssueNumber, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments on %s/%s#%d: %w", r.Owner, r.Repo, r.IssueNumber, err)
+	}
+	for _, c := range comments {
+		if strings.Contains(c.GetBody(), marker) {
+			logging.Infof("comment with %s has been created on %s/%s#%d", marker, r.Owner, r.Repo, r.IssueNumber)
+			return &outcome{id: c.GetID(), url: c.GetHTMLURL()}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *createIssueCommentRequest) describe() *PlanStep {
+	target := fmt.Sprintf("%s/%s#%d", r.Owner, r.Repo, r.IssueNumber)
+	if r.IssueNumber == 0 {
+		target = fmt.Sprintf("%s/%s (migrated from #%d)", r.Owner, r.Repo, r.SourceIssueNumber)
+	}
+	return &PlanStep{
+		Action:  actionCreateIssueComment,
+		Target:  target,
+		Summary: fmt.Sprintf("body=%q", r.Body),
+	}
+}
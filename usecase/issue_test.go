@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aereal/migrate-gh-repo/config"
+	"github.com/aereal/migrate-gh-repo/domain"
+	"github.com/aereal/migrate-gh-repo/mapping"
+	"github.com/google/go-github/github"
+)
+
+func intRef(i int) *int { return &i }
+
+func TestCreateIssueRequest_resolve(t *testing.T) {
+	store := mapping.NewMemoryStore()
+	store.Put(&mapping.Entry{Kind: mapping.KindMilestone, SourceKey: "v1", TargetID: 10, TargetNumber: 4})
+
+	cases := []struct {
+		name    string
+		req     *createIssueRequest
+		want    *createIssueRequest
+		wantErr bool
+	}{
+		{
+			name: "created milestone",
+			req:  &createIssueRequest{SourceMilestone: "v1", IssueReq: &github.IssueRequest{}},
+			want: &createIssueRequest{SourceMilestone: "v1", IssueReq: &github.IssueRequest{Milestone: intRef(4)}},
+		},
+		{
+			name: "already resolved",
+			req:  &createIssueRequest{IssueReq: &github.IssueRequest{Milestone: intRef(2)}},
+			want: &createIssueRequest{IssueReq: &github.IssueRequest{Milestone: intRef(2)}},
+		},
+		{
+			name: "no milestone",
+			req:  &createIssueRequest{IssueReq: &github.IssueRequest{}},
+			want: &createIssueRequest{IssueReq: &github.IssueRequest{}},
+		},
+		{
+			name:    "milestone not created",
+			req:     &createIssueRequest{SourceMilestone: "v2", IssueReq: &github.IssueRequest{}},
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.req.resolve(store)
+			if c.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(c.req, c.want) {
+				t.Errorf("got %#v, want %#v", c.req, c.want)
+			}
+		})
+	}
+}
+
+func TestCreateIssueCommentRequest_resolve(t *testing.T) {
+	store := mapping.NewMemoryStore()
+	store.Put(&mapping.Entry{Kind: mapping.KindIssue, SourceKey: "3", TargetID: 30, TargetNumber: 5})
+
+	req := &createIssueCommentRequest{SourceIssueNumber: 3}
+	if err := req.resolve(store); err != nil {
+		t.Fatal(err)
+	}
+	if req.IssueNumber != 5 {
+		t.Errorf("IssueNumber = %d, want 5", req.IssueNumber)
+	}
+
+	req = &createIssueCommentRequest{IssueNumber: 1, SourceIssueNumber: 4}
+	if err := req.resolve(store); err != nil {
+		t.Fatal(err)
+	}
+	if req.IssueNumber != 1 {
+		t.Errorf("IssueNumber = %d, want 1", req.IssueNumber)
+	}
+
+	req = &createIssueCommentRequest{SourceIssueNumber: 4}
+	if err := req.resolve(store); err == nil {
+		t.Error("expected error for the issue not migrated yet")
+	}
+}
+
+func TestUsecase_buildIssueRequests_references(t *testing.T) {
+	bodies := map[int]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/aereal/src/issues":
+			w.Write([]byte(`[
+				{"id":1,"number":1,"state":"open","title":"first","body":"see #2","html_url":"https://github.com/aereal/src/issues/1"},
+				{"id":2,"number":2,"state":"open","title":"second","body":"follow-up of #1 and https://github.com/aereal/src/issues/1","html_url":"https://github.com/aereal/src/issues/2"}
+			]`))
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/aereal/dest/issues":
+			w.Write([]byte(`[]`))
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/aereal/dest/issues":
+			req := &github.IssueRequest{}
+			json.NewDecoder(r.Body).Decode(req)
+			number := 10 + len(bodies)
+			bodies[number] = req.GetBody()
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(&github.Issue{ID: github.Int64(int64(number)), Number: &number})
+		case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/repos/aereal/dest/issues/"):
+			var number int
+			fmt.Sscanf(r.URL.Path, "/repos/aereal/dest/issues/%d", &number)
+			req := &github.IssueRequest{}
+			json.NewDecoder(r.Body).Decode(req)
+			bodies[number] = req.GetBody()
+			json.NewEncoder(w).Encode(&github.Issue{ID: github.Int64(int64(number)), Number: &number})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	header, err := domain.NewAttributionTemplate("from {{.URL}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := newTestUsecase(t, srv, WithCopiedIssueBody(header), WithWebURLs("https://github.com", "https://github.com"))
+	source := &config.Repository{Owner: "aereal", Name: "src"}
+	target := &config.Repository{Owner: "aereal", Name: "dest"}
+
+	reqs, err := u.buildIssueRequests(context.Background(), source, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Apply(context.Background(), newTestPlan(t, reqs...)); err != nil {
+		t.Fatal(err)
+	}
+	wants := map[int]string{
+		// #1 refers #2 created after it, so the body is updated at last
+		10: "from https://github.com/aereal/src/issues/1\n\nsee #11",
+		11: "from https://github.com/aereal/src/issues/2\n\nfollow-up of #10 and https://github.com/aereal/dest/issues/10",
+	}
+	for number, want := range wants {
+		if got := strings.SplitN(bodies[number], "\n\n<!--", 2)[0]; got != want {
+			t.Errorf("body of #%d = %q, want %q", number, got, want)
+		}
+	}
+}
@@ -3,10 +3,12 @@ package usecase
 import (
 	"context"
 	"fmt"
-	"log"
+	"net/http"
 
 	"github.com/aereal/migrate-gh-repo/config"
 	"github.com/aereal/migrate-gh-repo/domain"
+	"github.com/aereal/migrate-gh-repo/logging"
+	"github.com/aereal/migrate-gh-repo/mapping"
 	"github.com/google/go-github/github"
 )
 
@@ -20,58 +22,137 @@ func (u *Usecase) buildLabelRequests(ctx context.Context, source, target *config
 		return nil, fmt.Errorf("failed to fetch labels from target repository: %w", err)
 	}
 
+	targetByName := map[string]*github.Label{}
+	for _, l := range targetLabels {
+		targetByName[l.GetName()] = l
+	}
+	// the first one of labels merged by the mapping is told as the origin of the label to be created, and the others as merged ones
+	originByName := map[string]*origin{}
+	for _, l := range sourceLabels {
+		name := u.labelMapper.Map(l.GetName())
+		if t, ok := targetByName[name]; ok {
+			if err := u.mappings.Put(mapping.NewEntry(labelRef(l), t.GetID(), 0, t.GetURL())); err != nil {
+				return nil, err
+			}
+		}
+		if o, ok := originByName[name]; ok {
+			o.Merged = append(o.Merged, labelRef(l))
+		} else {
+			originByName[name] = &origin{Source: labelRef(l)}
+		}
+	}
+
 	reqs := []request{}
-	ops := domain.NewLabelOpsList(sourceLabels, targetLabels)
+	ops := domain.NewLabelOpsList(u.labelMapper.MapLabels(sourceLabels), targetLabels, u.labelConflictPolicy)
 	for _, op := range ops {
-		reqs = append(reqs, newLabelRequest(target, op))
+		reqs = append(reqs, newLabelRequest(target, op, originByName[op.Label.GetName()]))
+	}
+	if u.pruneLabels {
+		for _, op := range domain.NewLabelPruneOpsList(u.labelMapper.MapLabels(sourceLabels), targetLabels) {
+			reqs = append(reqs, newLabelRequest(target, op, nil))
+		}
 	}
 	return reqs, nil
 }
 
 type createLabelRequest struct {
-	owner string
-	repo  string
-	label *github.Label
+	origin
+	Owner string        `json:"owner"`
+	Repo  string        `json:"repo"`
+	Label *github.Label `json:"label"`
+}
+
+func (r *createLabelRequest) Do(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	label, resp, err := ghClient.Issues.CreateLabel(ctx, r.Owner, r.Repo, r.Label)
+	if err != nil {
+		return nil, err
+	}
+	logging.Infof("create label owner=%s repo=%s statusCode=%d label=%s", r.Owner, r.Repo, resp.StatusCode, r.Label)
+	return &outcome{id: label.GetID(), url: label.GetURL()}, nil
 }
 
-func (r *createLabelRequest) Do(ctx context.Context, ghClient *github.Client) error {
-	_, resp, err := ghClient.Issues.CreateLabel(ctx, r.owner, r.repo, r.label)
+func (r *createLabelRequest) findCreated(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	label, _, err := ghClient.Issues.GetLabel(ctx, r.Owner, r.Repo, r.Label.GetName())
+	if hasStatus(err, http.StatusNotFound) {
+		return nil, nil
+	}
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to get label %q on %s/%s: %w", r.Label.GetName(), r.Owner, r.Repo, err)
+	}
+	return &outcome{id: label.GetID(), url: label.GetURL()}, nil
+}
+
+func (r *createLabelRequest) describe() *PlanStep {
+	return &PlanStep{
+		Action:  actionCreateLabel,
+		Target:  fmt.Sprintf("%s/%s", r.Owner, r.Repo),
+		Summary: fmt.Sprintf("name=%q color=%q description=%q", r.Label.GetName(), r.Label.GetColor(), r.Label.GetDescription()),
 	}
-	log.Printf("create label owner=%s repo=%s statusCode=%d label=%s", r.owner, r.repo, resp.StatusCode, r.label)
-	return nil
 }
 
 type updateLabelRequest struct {
-	owner string
-	repo  string
-	name  string
-	label *github.Label
+	Owner string        `json:"owner"`
+	Repo  string        `json:"repo"`
+	Name  string        `json:"name"`
+	Label *github.Label `json:"label"`
 }
 
-func (r *updateLabelRequest) Do(ctx context.Context, ghClient *github.Client) error {
-	log.Printf("update label name=%s owner=%s repo=%s label=%s", r.name, r.owner, r.repo, r.label)
-	_, _, err := ghClient.Issues.EditLabel(ctx, r.owner, r.repo, r.name, r.label)
+func (r *updateLabelRequest) Do(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	logging.Infof("update label name=%s owner=%s repo=%s label=%s", r.Name, r.Owner, r.Repo, r.Label)
+	label, _, err := ghClient.Issues.EditLabel(ctx, r.Owner, r.Repo, r.Name, r.Label)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return &outcome{id: label.GetID()}, nil
+}
+
+func (r *updateLabelRequest) idempotent() {}
+
+func (r *updateLabelRequest) describe() *PlanStep {
+	return &PlanStep{
+		Action:  actionUpdateLabel,
+		Target:  fmt.Sprintf("%s/%s", r.Owner, r.Repo),
+		Summary: fmt.Sprintf("name=%q color=%q description=%q", r.Name, r.Label.GetColor(), r.Label.GetDescription()),
+	}
+}
+
+type deleteLabelRequest struct {
+	Owner string `json:"owner"`
+	Repo  string `json:"repo"`
+	Name  string `json:"name"`
+}
+
+func (r *deleteLabelRequest) Do(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	logging.Infof("delete label name=%s owner=%s repo=%s", r.Name, r.Owner, r.Repo)
+	if _, err := ghClient.Issues.DeleteLabel(ctx, r.Owner, r.Repo, r.Name); err != nil {
+		return nil, err
+	}
+	return &outcome{}, nil
+}
+
+func (r *deleteLabelRequest) describe() *PlanStep {
+	return &PlanStep{
+		Action:  actionDeleteLabel,
+		Target:  fmt.Sprintf("%s/%s", r.Owner, r.Repo),
+		Summary: fmt.Sprintf("name=%q", r.Name),
 	}
-	return nil
 }
 
-func newLabelRequest(repo *config.Repository, op *domain.LabelOp) request {
+func newLabelRequest(repo *config.Repository, op *domain.LabelOp, source *origin) request {
 	switch op.Kind {
 	case domain.OpCreate:
-		return &createLabelRequest{owner: repo.Owner, repo: repo.Name, label: &github.Label{
+		return &createLabelRequest{origin: *source, Owner: repo.Owner, Repo: repo.Name, Label: &github.Label{
 			Name:        op.Label.Name,
 			Color:       op.Label.Color,
 			Description: op.Label.Description,
 		}}
 	case domain.OpUpdate:
-		return &updateLabelRequest{owner: repo.Owner, repo: repo.Name, name: op.Label.GetName(), label: &github.Label{
+		return &updateLabelRequest{Owner: repo.Owner, Repo: repo.Name, Name: op.Label.GetName(), Label: &github.Label{
 			Color:       op.Label.Color,
 			Description: op.Label.Description,
 		}}
+	case domain.OpDelete:
+		return &deleteLabelRequest{Owner: repo.Owner, Repo: repo.Name, Name: op.Label.GetName()}
 	default:
 		return nil
 	}
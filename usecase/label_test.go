@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aereal/migrate-gh-repo/config"
+	"github.com/aereal/migrate-gh-repo/domain"
+	"github.com/aereal/migrate-gh-repo/mapping"
+	"github.com/google/go-github/github"
+)
+
+// labelListingServer serves the given labels of each repository and creates any label on aereal/dest with ID 300.
+func labelListingServer(labels map[string][]*github.Label) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(labels[r.URL.Path])
+		case http.MethodPost:
+			label := &github.Label{}
+			json.NewDecoder(r.Body).Decode(label)
+			label.ID = github.Int64(300)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(label)
+		}
+	}))
+}
+
+func TestUsecase_buildLabelRequests_merged(t *testing.T) {
+	srv := labelListingServer(map[string][]*github.Label{
+		"/repos/aereal/src/labels": {
+			{ID: github.Int64(1), Name: strRef("bug"), Color: strRef("ff0000")},
+			{ID: github.Int64(2), Name: strRef("defect"), Color: strRef("00ff00")},
+		},
+	})
+	defer srv.Close()
+	u := newTestUsecase(t, srv, WithLabelMapping(domain.NewLabelMapper(map[string]string{"bug": "type: bug", "defect": "type: bug"})))
+	source := &config.Repository{Owner: "aereal", Name: "src"}
+	target := &config.Repository{Owner: "aereal", Name: "dest"}
+
+	reqs, err := u.buildLabelRequests(context.Background(), source, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 1 {
+		t.Fatalf("len(reqs) = %d, want 1", len(reqs))
+	}
+
+	plan := newTestPlan(t, reqs...)
+	if err := u.Apply(context.Background(), plan); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"bug", "defect"} {
+		if entry, ok := u.mappings.Lookup(mapping.KindLabel, name); !ok || entry.TargetID != 300 {
+			t.Errorf("Lookup(label, %s) = %#v, %v", name, entry, ok)
+		}
+	}
+}
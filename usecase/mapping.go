@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/aereal/migrate-gh-repo/domain"
+	"github.com/aereal/migrate-gh-repo/journal"
+	"github.com/aereal/migrate-gh-repo/mapping"
+	"github.com/google/go-github/github"
+)
+
+// origin tells which entity on the source repository the request migrates.
+type origin struct {
+	Source *mapping.Ref   `json:"source,omitempty"`
+	Merged []*mapping.Ref `json:"merged,omitempty"` // other entities on the source merged into the same one (e.g. labels by labelMapping)
+}
+
+func (o *origin) sourceRefs() []*mapping.Ref {
+	if o.Source == nil {
+		return nil
+	}
+	return append([]*mapping.Ref{o.Source}, o.Merged...)
+}
+
+// sourced is implemented by requests creating the counterpart of entities on the source repository.
+type sourced interface {
+	sourceRefs() []*mapping.Ref
+}
+
+// resolvable is implemented by requests referring to entities which are created by preceding steps.
+type resolvable interface {
+	resolve(store *mapping.Store) error
+}
+
+func (u *Usecase) recordMapping(r request, out *outcome) error {
+	s, ok := r.(sourced)
+	if !ok {
+		return nil
+	}
+	for _, ref := range s.sourceRefs() {
+		entry := mapping.NewEntry(ref, out.id, out.number, out.url)
+		entry.TargetType = out.targetType
+		if err := u.mappings.Put(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreMapping records the identity of the entity created by the step completed in the previous run unless the mapping knows it.
+func (u *Usecase) restoreMapping(r request, entry *journal.Entry) error {
+	s, ok := r.(sourced)
+	if !ok {
+		return nil
+	}
+	for _, ref := range s.sourceRefs() {
+		if _, ok := u.mappings.Lookup(ref.Kind, ref.Key); ok {
+			continue
+		}
+		restored := mapping.NewEntry(ref, entry.TargetID, entry.TargetNumber, "")
+		restored.TargetType = entry.TargetType
+		if err := u.mappings.Put(restored); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func lookupMapping(store *mapping.Store, kind mapping.Kind, key string) (*mapping.Entry, error) {
+	entry, ok := store.Lookup(kind, key)
+	if !ok {
+		return nil, fmt.Errorf("no %s mapped from %q on the source repository", kind, key)
+	}
+	return entry, nil
+}
+
+func milestoneRef(m *github.Milestone) *mapping.Ref {
+	return &mapping.Ref{Kind: mapping.KindMilestone, Key: m.GetTitle(), ID: m.GetID(), URL: m.GetHTMLURL()}
+}
+
+func labelRef(l *github.Label) *mapping.Ref {
+	return &mapping.Ref{Kind: mapping.KindLabel, Key: l.GetName(), ID: l.GetID(), URL: l.GetURL()}
+}
+
+func issueRef(i *github.Issue) *mapping.Ref {
+	return &mapping.Ref{Kind: mapping.KindIssue, Key: strconv.Itoa(i.GetNumber()), ID: i.GetID(), URL: i.GetHTMLURL()}
+}
+
+func issueCommentRef(c *github.IssueComment) *mapping.Ref {
+	return &mapping.Ref{Kind: mapping.KindComment, Key: strconv.FormatInt(c.GetID(), 10), ID: c.GetID(), URL: c.GetHTMLURL()}
+}
+
+func reviewRef(r *github.PullRequestReview) *mapping.Ref {
+	return &mapping.Ref{Kind: mapping.KindReview, Key: strconv.FormatInt(r.GetID(), 10), ID: r.GetID(), URL: r.GetHTMLURL()}
+}
+
+func reviewThreadRef(t *domain.ReviewThread) *mapping.Ref {
+	root := t.Root()
+	return &mapping.Ref{Kind: mapping.KindReviewThread, Key: strconv.FormatInt(root.GetID(), 10), ID: root.GetID(), URL: root.GetHTMLURL()}
+}
+
+func projectRef(p *github.Project) *mapping.Ref {
+	return &mapping.Ref{Kind: mapping.KindProject, Key: p.GetName(), ID: p.GetID(), URL: p.GetURL()}
+}
+
+func projectColumnRef(p *github.Project, c *github.ProjectColumn) *mapping.Ref {
+	return &mapping.Ref{Kind: mapping.KindProjectColumn, Key: projectColumnKey(p, c), ID: c.GetID()}
+}
+
+func projectColumnKey(p *github.Project, c *github.ProjectColumn) string {
+	return fmt.Sprintf("%s/%s", p.GetName(), c.GetName())
+}
+
+func projectCardRef(c *github.ProjectCard) *mapping.Ref {
+	return &mapping.Ref{Kind: mapping.KindProjectCard, Key: strconv.FormatInt(c.GetID(), 10), ID: c.GetID(), URL: c.GetURL()}
+}
+
+func releaseRef(r *github.RepositoryRelease) *mapping.Ref {
+	return &mapping.Ref{Kind: mapping.KindRelease, Key: r.GetTagName(), ID: r.GetID(), URL: r.GetHTMLURL()}
+}
+
+func releaseAssetRef(r *github.RepositoryRelease, a *github.ReleaseAsset) *mapping.Ref {
+	return &mapping.Ref{Kind: mapping.KindReleaseAsset, Key: fmt.Sprintf("%s/%s", r.GetTagName(), a.GetName()), ID: a.GetID(), URL: a.GetBrowserDownloadURL()}
+}
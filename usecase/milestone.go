@@ -3,78 +3,220 @@ package usecase
 import (
 	"context"
 	"fmt"
-	"log"
 
 	"github.com/aereal/migrate-gh-repo/config"
 	"github.com/aereal/migrate-gh-repo/domain"
+	"github.com/aereal/migrate-gh-repo/logging"
+	"github.com/aereal/migrate-gh-repo/mapping"
 	"github.com/google/go-github/github"
 )
 
-func (u *Usecase) buildMilestoneRequests(ctx context.Context, source, target *config.Repository) ([]request, error) {
+// buildMilestoneRequests builds requests to create or update milestones, and ones to close them after issues are assigned.
+func (u *Usecase) buildMilestoneRequests(ctx context.Context, source, target *config.Repository) ([]request, []request, error) {
 	sourceMilestones, err := u.sourceService.SlurpMilestones(ctx, source.Owner, source.Name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch milestones from source repository: %w", err)
+		return nil, nil, fmt.Errorf("failed to fetch milestones from source repository: %w", err)
 	}
 	targetMilestones, err := u.targetService.SlurpMilestones(ctx, target.Owner, target.Name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch milestones from target repository: %w", err)
+		return nil, nil, fmt.Errorf("failed to fetch milestones from target repository: %w", err)
+	}
+	for i, m := range sourceMilestones {
+		sourceMilestones[i] = domain.AnnotateClosingDate(m)
+	}
+
+	targetByTitle := map[string]*github.Milestone{}
+	for _, m := range targetMilestones {
+		targetByTitle[m.GetTitle()] = m
+	}
+	for _, m := range sourceMilestones {
+		if t, ok := targetByTitle[m.GetTitle()]; ok {
+			if err := u.mappings.Put(mapping.NewEntry(milestoneRef(m), t.GetID(), t.GetNumber(), t.GetHTMLURL())); err != nil {
+				return nil, nil, err
+			}
+		}
 	}
 
 	reqs := []request{}
+	closingReqs := []request{}
 	ops := domain.NewMilestoneOpsList(sourceMilestones, targetMilestones)
 	for _, op := range ops {
+		targetNumber := 0
+		if op.Kind == domain.OpUpdate {
+			// the source number may point at another milestone on the target
+			targetNumber = targetByTitle[op.Milestone.GetTitle()].GetNumber()
+			updated := *op.Milestone
+			updated.Number = &targetNumber
+			op = &domain.MilestoneOp{Kind: op.Kind, Milestone: &updated}
+		}
 		reqs = append(reqs, newMilestoneRequest(target, op))
 	}
-	return reqs, nil
+	// milestones created or updated are opened, so closed ones are closed again as well as ones left open on the target
+	toClose := map[string]bool{}
+	for _, op := range ops {
+		if (op.Kind == domain.OpCreate || op.Kind == domain.OpUpdate) && op.Milestone.GetState() == "closed" {
+			toClose[op.Milestone.GetTitle()] = true
+		}
+	}
+	for _, m := range domain.MilestonesToClose(sourceMilestones, targetMilestones) {
+		toClose[m.GetTitle()] = true
+	}
+	for _, m := range sourceMilestones {
+		if !toClose[m.GetTitle()] {
+			continue
+		}
+		closingReqs = append(closingReqs, &closeMilestoneRequest{
+			Owner:           target.Owner,
+			Repo:            target.Name,
+			Number:          targetByTitle[m.GetTitle()].GetNumber(),
+			SourceMilestone: m.GetTitle(),
+		})
+	}
+	if u.pruneMilestones {
+		for _, op := range domain.NewMilestonePruneOpsList(sourceMilestones, targetMilestones) {
+			reqs = append(reqs, newMilestoneRequest(target, op))
+		}
+	}
+	return reqs, closingReqs, nil
 }
 
 type createMilestoneRequest struct {
-	owner     string
-	repo      string
-	milestone *github.Milestone
+	origin
+	Owner     string            `json:"owner"`
+	Repo      string            `json:"repo"`
+	Milestone *github.Milestone `json:"milestone"`
 }
 
-func (r *createMilestoneRequest) Do(ctx context.Context, ghClient *github.Client) error {
-	_, resp, err := ghClient.Issues.CreateMilestone(ctx, r.owner, r.repo, r.milestone)
+func (r *createMilestoneRequest) Do(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	milestone, resp, err := ghClient.Issues.CreateMilestone(ctx, r.Owner, r.Repo, r.Milestone)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	logging.Infof("create milestone owner=%s repo=%s statusCode=%d milestone=%s", r.Owner, r.Repo, resp.StatusCode, r.Milestone)
+	return &outcome{id: milestone.GetID(), number: milestone.GetNumber(), url: milestone.GetHTMLURL()}, nil
+}
+
+func (r *createMilestoneRequest) describe() *PlanStep {
+	return &PlanStep{
+		Action:  actionCreateMilestone,
+		Target:  fmt.Sprintf("%s/%s", r.Owner, r.Repo),
+		Summary: fmt.Sprintf("title=%q state=%q", r.Milestone.GetTitle(), r.Milestone.GetState()),
 	}
-	log.Printf("create milestone owner=%s repo=%s statusCode=%d milestone=%s", r.owner, r.repo, resp.StatusCode, r.milestone)
-	return nil
 }
 
 type updateMilestoneRequest struct {
-	owner     string
-	repo      string
-	number    int
-	milestone *github.Milestone
+	Owner     string            `json:"owner"`
+	Repo      string            `json:"repo"`
+	Number    int               `json:"number"`
+	Milestone *github.Milestone `json:"milestone"`
 }
 
-func (r *updateMilestoneRequest) Do(ctx context.Context, ghClient *github.Client) error {
-	log.Printf("update milestone number=%d owner=%s repo=%s milestone=%s", r.number, r.owner, r.repo, r.milestone)
-	_, _, err := ghClient.Issues.EditMilestone(ctx, r.owner, r.repo, r.number, r.milestone)
+func (r *updateMilestoneRequest) Do(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	logging.Infof("update milestone number=%d owner=%s repo=%s milestone=%s", r.Number, r.Owner, r.Repo, r.Milestone)
+	milestone, _, err := ghClient.Issues.EditMilestone(ctx, r.Owner, r.Repo, r.Number, r.Milestone)
+	if err != nil {
+		return nil, err
+	}
+	return &outcome{id: milestone.GetID(), number: milestone.GetNumber()}, nil
+}
+
+func (r *updateMilestoneRequest) idempotent() {}
+
+func (r *updateMilestoneRequest) describe() *PlanStep {
+	return &PlanStep{
+		Action:  actionUpdateMilestone,
+		Target:  fmt.Sprintf("%s/%s", r.Owner, r.Repo),
+		Summary: fmt.Sprintf("number=%d title=%q state=%q", r.Number, r.Milestone.GetTitle(), r.Milestone.GetState()),
+	}
+}
+
+type closeMilestoneRequest struct {
+	Owner           string `json:"owner"`
+	Repo            string `json:"repo"`
+	Number          int    `json:"number,omitempty"`
+	SourceMilestone string `json:"sourceMilestone,omitempty"` // resolved to Number on apply if the milestone has not been created yet
+}
+
+func (r *closeMilestoneRequest) resolve(store *mapping.Store) error {
+	if r.Number != 0 {
+		return nil
+	}
+	entry, err := lookupMapping(store, mapping.KindMilestone, r.SourceMilestone)
 	if err != nil {
 		return err
 	}
+	r.Number = entry.TargetNumber
 	return nil
 }
 
+func (r *closeMilestoneRequest) Do(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	logging.Infof("close milestone number=%d owner=%s repo=%s", r.Number, r.Owner, r.Repo)
+	closed := "closed"
+	milestone, _, err := ghClient.Issues.EditMilestone(ctx, r.Owner, r.Repo, r.Number, &github.Milestone{State: &closed})
+	if err != nil {
+		return nil, err
+	}
+	return &outcome{id: milestone.GetID(), number: milestone.GetNumber()}, nil
+}
+
+func (r *closeMilestoneRequest) idempotent() {}
+
+func (r *closeMilestoneRequest) describe() *PlanStep {
+	summary := fmt.Sprintf("title=%q", r.SourceMilestone)
+	if r.Number != 0 {
+		summary = fmt.Sprintf("number=%d title=%q", r.Number, r.SourceMilestone)
+	}
+	return &PlanStep{
+		Action:  actionCloseMilestone,
+		Target:  fmt.Sprintf("%s/%s", r.Owner, r.Repo),
+		Summary: summary,
+	}
+}
+
+type deleteMilestoneRequest struct {
+	Owner  string `json:"owner"`
+	Repo   string `json:"repo"`
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+}
+
+func (r *deleteMilestoneRequest) Do(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	logging.Infof("delete milestone number=%d title=%s owner=%s repo=%s", r.Number, r.Title, r.Owner, r.Repo)
+	if _, err := ghClient.Issues.DeleteMilestone(ctx, r.Owner, r.Repo, r.Number); err != nil {
+		return nil, err
+	}
+	return &outcome{}, nil
+}
+
+func (r *deleteMilestoneRequest) describe() *PlanStep {
+	return &PlanStep{
+		Action:  actionDeleteMilestone,
+		Target:  fmt.Sprintf("%s/%s", r.Owner, r.Repo),
+		Summary: fmt.Sprintf("number=%d title=%q", r.Number, r.Title),
+	}
+}
+
+// newMilestoneRequest builds the request to create or update the milestone.
+// Milestones are kept open until closeMilestoneRequest closes them after issues are assigned.
 func newMilestoneRequest(repo *config.Repository, op *domain.MilestoneOp) request {
+	open := "open"
 	switch op.Kind {
 	case domain.OpCreate:
-		return &createMilestoneRequest{owner: repo.Owner, repo: repo.Name, milestone: &github.Milestone{
-			State:       op.Milestone.State,
+		return &createMilestoneRequest{origin: origin{Source: milestoneRef(op.Milestone)}, Owner: repo.Owner, Repo: repo.Name, Milestone: &github.Milestone{
+			State:       &open,
 			Title:       op.Milestone.Title,
 			Description: op.Milestone.Description,
 			DueOn:       op.Milestone.DueOn,
 		}}
 	case domain.OpUpdate:
-		return &updateMilestoneRequest{owner: repo.Owner, repo: repo.Name, number: op.Milestone.GetNumber(), milestone: &github.Milestone{
-			State:       op.Milestone.State,
+		return &updateMilestoneRequest{Owner: repo.Owner, Repo: repo.Name, Number: op.Milestone.GetNumber(), Milestone: &github.Milestone{
+			State:       &open,
 			Title:       op.Milestone.Title,
 			Description: op.Milestone.Description,
 			DueOn:       op.Milestone.DueOn,
 		}}
+	case domain.OpDelete:
+		return &deleteMilestoneRequest{Owner: repo.Owner, Repo: repo.Name, Number: op.Milestone.GetNumber(), Title: op.Milestone.GetTitle()}
 	default:
 		return nil
 	}
@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aereal/migrate-gh-repo/config"
+)
+
+func TestUsecase_buildMilestoneRequests_closing(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/aereal/src/milestones":
+			w.Write([]byte(`[
+				{"id":1,"number":1,"title":"v1","state":"closed","description":"first release","closed_at":"2019-01-02T03:04:05Z"},
+				{"id":2,"number":2,"title":"v2","state":"closed","description":"second release","closed_at":"2019-02-02T03:04:05Z"}
+			]`))
+		case "/repos/aereal/dest/milestones":
+			// v1 is migrated by the previous version without the note of the closing date
+			w.Write([]byte(`[{"id":70,"number":7,"title":"v1","state":"closed","description":"first release"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	u := newTestUsecase(t, srv)
+	source := &config.Repository{Owner: "aereal", Name: "src"}
+	target := &config.Repository{Owner: "aereal", Name: "dest"}
+
+	reqs, closingReqs, err := u.buildMilestoneRequests(context.Background(), source, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 2 {
+		t.Fatalf("len(reqs) = %d, want 2", len(reqs))
+	}
+	if r, ok := reqs[0].(*updateMilestoneRequest); !ok || r.Number != 7 || r.Milestone.GetState() != "open" {
+		t.Errorf("reqs[0] = %#v, want the update of #7", reqs[0])
+	}
+	want := []closeMilestoneRequest{
+		{Owner: "aereal", Repo: "dest", Number: 7, SourceMilestone: "v1"},
+		{Owner: "aereal", Repo: "dest", SourceMilestone: "v2"},
+	}
+	if len(closingReqs) != len(want) {
+		t.Fatalf("len(closingReqs) = %d, want %d", len(closingReqs), len(want))
+	}
+	for i, r := range closingReqs {
+		if got, ok := r.(*closeMilestoneRequest); !ok || *got != want[i] {
+			t.Errorf("closingReqs[%d] = %#v, want %#v", i, r, want[i])
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/format"
+	"github.com/aereal/migrate-gh-repo/config"
+)
+
+const (
+	actionCreateMilestone     = "create_milestone"
+	actionUpdateMilestone     = "update_milestone"
+	actionDeleteMilestone     = "delete_milestone"
+	actionCloseMilestone      = "close_milestone"
+	actionCreateLabel         = "create_label"
+	actionUpdateLabel         = "update_label"
+	actionDeleteLabel         = "delete_label"
+	actionCreateIssue         = "create_issue"
+	actionUpdateIssue         = "update_issue"
+	actionUpdateIssueBody     = "update_issue_body"
+	actionCreateIssueComment  = "create_issue_comment"
+	actionCreatePullRequest   = "create_pull_request"
+	actionCreateProject       = "create_project"
+	actionCreateProjectColumn = "create_project_column"
+	actionCreateProjectCard   = "create_project_card"
+	actionCreateRelease       = "create_release"
+	actionUploadReleaseAsset  = "upload_release_asset"
+)
+
+var requestFactories = map[string]func() request{
+	actionCreateMilestone:     func() request { return &createMilestoneRequest{} },
+	actionUpdateMilestone:     func() request { return &updateMilestoneRequest{} },
+	actionDeleteMilestone:     func() request { return &deleteMilestoneRequest{} },
+	actionCloseMilestone:      func() request { return &closeMilestoneRequest{} },
+	actionCreateLabel:         func() request { return &createLabelRequest{} },
+	actionUpdateLabel:         func() request { return &updateLabelRequest{} },
+	actionDeleteLabel:         func() request { return &deleteLabelRequest{} },
+	actionCreateIssue:         func() request { return &createIssueRequest{} },
+	actionUpdateIssue:         func() request { return &updateIssueRequest{} },
+	actionUpdateIssueBody:     func() request { return &updateIssueBodyRequest{} },
+	actionCreateIssueComment:  func() request { return &createIssueCommentRequest{} },
+	actionCreatePullRequest:   func() request { return &createPullRequestRequest{} },
+	actionCreateProject:       func() request { return &createProjectRequest{} },
+	actionCreateProjectColumn: func() request { return &createProjectColumnRequest{} },
+	actionCreateProjectCard:   func() request { return &createProjectCardRequest{} },
+	actionCreateRelease:       func() request { return &createReleaseRequest{} },
+	actionUploadReleaseAsset:  func() request { return &uploadReleaseAssetRequest{} },
+}
+
+// destructiveActions delete entities existing on the target repository.
+var destructiveActions = map[string]bool{
+	actionDeleteMilestone: true,
+	actionDeleteLabel:     true,
+}
+
+type Plan struct {
+	Source string      `json:"source"`
+	Target string      `json:"target"`
+	Steps  []*PlanStep `json:"steps"`
+}
+
+type PlanStep struct {
+	Action  string          `json:"action"`
+	Target  string          `json:"target"`
+	Summary string          `json:"summary"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func newPlanStep(r request) (*PlanStep, error) {
+	step := r.describe()
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload of %s: %w", step.Action, err)
+	}
+	step.Payload = payload
+	return step, nil
+}
+
+func (s *PlanStep) request() (request, error) {
+	newRequest, ok := requestFactories[s.Action]
+	if !ok {
+		return nil, fmt.Errorf("unknown action: %q", s.Action)
+	}
+	r := newRequest()
+	if err := json.Unmarshal(s.Payload, r); err != nil {
+		return nil, fmt.Errorf("failed to decode payload of %s: %w", s.Action, err)
+	}
+	return r, nil
+}
+
+func (u *Usecase) Plan(ctx context.Context, source, target *config.Repository) (*Plan, error) {
+	if source == nil || target == nil {
+		return nil, fmt.Errorf("Both of from/to repository must be given")
+	}
+
+	reqs, err := u.buildRequests(ctx, source, target)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{
+		Source: fmt.Sprintf("%s/%s", source.Owner, source.Name),
+		Target: fmt.Sprintf("%s/%s", target.Owner, target.Name),
+		Steps:  make([]*PlanStep, len(reqs)),
+	}
+	for i, r := range reqs {
+		step, err := newPlanStep(r)
+		if err != nil {
+			return nil, err
+		}
+		plan.Steps[i] = step
+	}
+	return plan, nil
+}
+
+func LoadPlan(path string) (*Plan, error) {
+	plan := &Plan{}
+	switch filepath.Ext(path) {
+	case ".cue":
+		r := &cue.Runtime{}
+		inst, err := r.Compile(path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile plan (%q): %w", path, err)
+		}
+		if err := inst.Value().Decode(plan); err != nil {
+			return nil, fmt.Errorf("failed to decode plan (%q): %w", path, err)
+		}
+	default:
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read plan (%q): %w", path, err)
+		}
+		if err := json.Unmarshal(b, plan); err != nil {
+			return nil, fmt.Errorf("failed to decode plan (%q): %w", path, err)
+		}
+	}
+	for i, step := range plan.Steps {
+		if _, err := step.request(); err != nil {
+			return nil, fmt.Errorf("invalid step #%d in plan (%q): %w", i+1, path, err)
+		}
+	}
+	return plan, nil
+}
+
+func (p *Plan) Save(path string) error {
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+	if filepath.Ext(path) == ".cue" {
+		r := &cue.Runtime{}
+		inst, err := r.Compile(path, b)
+		if err != nil {
+			return fmt.Errorf("failed to convert plan into CUE: %w", err)
+		}
+		b, err = format.Node(inst.Value().Syntax())
+		if err != nil {
+			return fmt.Errorf("failed to format plan: %w", err)
+		}
+	}
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("failed to write plan (%q): %w", path, err)
+	}
+	return nil
+}
+
+func (p *Plan) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "#\tACTION\tTARGET\tSUMMARY\n")
+	for i, step := range p.Steps {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", i+1, step.Action, step.Target, truncateSummary(step.Summary))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "\n%s -> %s: %s\n", p.Source, p.Target, p.summarize()); err != nil {
+		return err
+	}
+	if n := p.countDestructive(); n > 0 {
+		if _, err := fmt.Fprintf(w, "WARNING: %d requests delete labels or milestones existing on %s\n", n, p.Target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maxSummaryLength is the number of characters of summaries shown in the table; the whole summary is kept in JSON.
+const maxSummaryLength = 80
+
+// truncateSummary shortens the summary into one line not to break the table with long bodies.
+func truncateSummary(summary string) string {
+	if i := strings.IndexAny(summary, "\r\n"); i >= 0 {
+		summary = summary[:i] + "..."
+	}
+	runes := []rune(summary)
+	if len(runes) > maxSummaryLength {
+		return string(runes[:maxSummaryLength-3]) + "..."
+	}
+	return summary
+}
+
+func (p *Plan) countDestructive() int {
+	n := 0
+	for _, step := range p.Steps {
+		if destructiveActions[step.Action] {
+			n++
+		}
+	}
+	return n
+}
+
+func (p *Plan) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+func (p *Plan) summarize() string {
+	if len(p.Steps) == 0 {
+		return "no changes"
+	}
+	counts := map[string]int{}
+	actions := []string{}
+	for _, step := range p.Steps {
+		if _, ok := counts[step.Action]; !ok {
+			actions = append(actions, step.Action)
+		}
+		counts[step.Action]++
+	}
+	sort.Strings(actions)
+	parts := make([]string, len(actions))
+	for i, action := range actions {
+		parts[i] = fmt.Sprintf("%s=%d", action, counts[action])
+	}
+	return fmt.Sprintf("%d requests (%s)", len(p.Steps), strings.Join(parts, ", "))
+}
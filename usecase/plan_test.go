@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aereal/migrate-gh-repo/mapping"
+	"github.com/google/go-github/github"
+)
+
+func strRef(s string) *string { return &s }
+
+func TestPlan_SaveAndLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	reqs := []request{
+		&createLabelRequest{origin: origin{Source: &mapping.Ref{Kind: mapping.KindLabel, Key: "bug"}, Merged: []*mapping.Ref{{Kind: mapping.KindLabel, Key: "defect"}}}, Owner: "aereal", Repo: "dest", Label: &github.Label{Name: strRef("bug"), Color: strRef("ff0000")}},
+		&createIssueRequest{Owner: "aereal", Repo: "dest", SourceMilestone: "v1", IssueReq: &github.IssueRequest{
+			Title:  strRef("poppoe"),
+			Body:   strRef("multi\nline \"body\""),
+			Labels: &[]string{"bug"},
+		}},
+		&createIssueCommentRequest{Owner: "aereal", Repo: "dest", IssueNumber: 1, Body: "hi"},
+		&createIssueCommentRequest{Owner: "aereal", Repo: "dest", SourceIssueNumber: 3, Body: "hi"},
+		&createPullRequestRequest{
+			Owner:       "aereal",
+			Repo:        "dest",
+			PullRequest: &github.NewPullRequest{Title: strRef("feature"), Head: strRef("feature"), Base: strRef("master")},
+			Fallback:    &createIssueRequest{Owner: "aereal", Repo: "dest", IssueReq: &github.IssueRequest{Title: strRef("feature")}},
+		},
+		&updateIssueRequest{Owner: "aereal", Repo: "dest", SourceIssueNumber: 2, IssueReq: &github.IssueRequest{Labels: &[]string{"bug"}}},
+		&deleteLabelRequest{Owner: "aereal", Repo: "dest", Name: "wontfix"},
+	}
+	plan := &Plan{Source: "aereal/src", Target: "aereal/dest"}
+	for _, r := range reqs {
+		step, err := newPlanStep(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+
+	for _, ext := range []string{".json", ".cue"} {
+		t.Run(ext, func(t *testing.T) {
+			path := filepath.Join(dir, "plan"+ext)
+			if err := plan.Save(path); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadPlan(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Steps) != len(reqs) {
+				t.Fatalf("len(Steps) = %d, want %d", len(got.Steps), len(reqs))
+			}
+			for i, step := range got.Steps {
+				r, err := step.request()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(r, reqs[i]) {
+					t.Errorf("step #%d = %#v, want %#v", i+1, r, reqs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPlan_WriteTable(t *testing.T) {
+	plan := &Plan{Source: "aereal/src", Target: "aereal/dest"}
+	for _, r := range []request{
+		&createLabelRequest{Owner: "aereal", Repo: "dest", Label: &github.Label{Name: strRef("bug")}},
+		&deleteLabelRequest{Owner: "aereal", Repo: "dest", Name: "wontfix"},
+		&deleteMilestoneRequest{Owner: "aereal", Repo: "dest", Number: 1, Title: "someday"},
+	} {
+		step, err := newPlanStep(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := plan.WriteTable(buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if got, want := lines[len(lines)-2], "aereal/src -> aereal/dest: 3 requests (create_label=1, delete_label=1, delete_milestone=1)"; got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+	if got, want := lines[len(lines)-1], "WARNING: 2 requests delete labels or milestones existing on aereal/dest"; got != want {
+		t.Errorf("warning = %q, want %q", got, want)
+	}
+}
+
+func TestPlan_WriteTable_truncatesSummary(t *testing.T) {
+	plan := &Plan{Source: "aereal/src", Target: "aereal/dest"}
+	step, err := newPlanStep(&createIssueCommentRequest{Owner: "aereal", Repo: "dest", IssueNumber: 1, Body: strings.Repeat("long comment ", 20)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan.Steps = append(plan.Steps, step)
+
+	buf := &bytes.Buffer{}
+	if err := plan.WriteTable(buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.HasSuffix(lines[1], "...") || strings.Contains(lines[1], strings.Repeat("long comment ", 10)) {
+		t.Errorf("summary not truncated: %q", lines[1])
+	}
+}
+
+func TestPlan_WriteJSON(t *testing.T) {
+	plan := &Plan{Source: "aereal/src", Target: "aereal/dest"}
+	step, err := newPlanStep(&createLabelRequest{Owner: "aereal", Repo: "dest", Label: &github.Label{Name: strRef("bug")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan.Steps = append(plan.Steps, step)
+
+	buf := &bytes.Buffer{}
+	if err := plan.WriteJSON(buf); err != nil {
+		t.Fatal(err)
+	}
+	got := &Plan{}
+	if err := json.Unmarshal(buf.Bytes(), got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Source != plan.Source || got.Target != plan.Target || len(got.Steps) != 1 || got.Steps[0].Action != actionCreateLabel {
+		t.Errorf("WriteJSON() = %s", buf.String())
+	}
+}
+
+func TestTruncateSummary(t *testing.T) {
+	cases := []struct {
+		summary string
+		want    string
+	}{
+		{summary: `name="bug"`, want: `name="bug"`},
+		{summary: "body=\"first\nsecond\"", want: "body=\"first..."},
+		{summary: strings.Repeat("a", 100), want: strings.Repeat("a", 77) + "..."},
+	}
+	for _, c := range cases {
+		if got := truncateSummary(c.summary); got != c.want {
+			t.Errorf("truncateSummary(%q) = %q, want %q", c.summary, got, c.want)
+		}
+	}
+}
@@ -3,16 +3,17 @@ package usecase
 import (
 	"context"
 	"fmt"
-	"log"
 	"strconv"
 	"strings"
 
 	"github.com/aereal/migrate-gh-repo/config"
 	"github.com/aereal/migrate-gh-repo/domain"
+	"github.com/aereal/migrate-gh-repo/logging"
+	"github.com/aereal/migrate-gh-repo/mapping"
 	"github.com/google/go-github/github"
 )
 
-func (u *Usecase) buildProjectRequests(ctx context.Context, source, target *config.Repository, issueMapping map[int]int64) ([]request, error) {
+func (u *Usecase) buildProjectRequests(ctx context.Context, source, target *config.Repository) ([]request, error) {
 	sourceProjects, err := u.sourceService.SlurpProjects(ctx, source.Owner, source.Name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch projects from source repository: %w", err)
@@ -28,19 +29,31 @@ func (u *Usecase) buildProjectRequests(ctx context.Context, source, target *conf
 		switch op.Kind {
 		case domain.OpCreate:
 			reqs = append(reqs, &createProjectRequest{
-				owner: target.Owner,
-				repo:  target.Name,
-				opts: &github.ProjectOptions{
+				origin: origin{Source: projectRef(op.Project)},
+				Owner:  target.Owner,
+				Repo:   target.Name,
+				Opts: &github.ProjectOptions{
 					Name: op.Project.GetName(),
 					Body: op.Project.GetBody(),
 				},
 			})
+			// columns are created on the project created above
+			columnReqs, err := u.buildProjectColumnRequests(ctx, op.Project, nil, source, target)
+			if err != nil {
+				return nil, err
+			}
+			logging.Debugf("%d project column requests", len(columnReqs))
+
+			reqs = append(reqs, columnReqs...)
 		case domain.OpUpdate:
-			columnReqs, err := u.buildProjectColumnRequests(ctx, op.Project, op.TargetProject, source, target, issueMapping)
+			if err := u.mappings.Put(mapping.NewEntry(projectRef(op.Project), op.TargetProject.GetID(), op.TargetProject.GetNumber(), op.TargetProject.GetURL())); err != nil {
+				return nil, err
+			}
+			columnReqs, err := u.buildProjectColumnRequests(ctx, op.Project, op.TargetProject, source, target)
 			if err != nil {
 				return nil, err
 			}
-			log.Printf("%d project column requests", len(columnReqs))
+			logging.Debugf("%d project column requests", len(columnReqs))
 
 			reqs = append(reqs, columnReqs...)
 		default:
@@ -48,34 +61,46 @@ func (u *Usecase) buildProjectRequests(ctx context.Context, source, target *conf
 		}
 	}
 
-	// TODO: accumulate project column, project column card
-
 	return reqs, nil
 }
 
 type createProjectRequest struct {
-	owner string
-	repo  string
-	opts  *github.ProjectOptions
+	origin
+	Owner string                 `json:"owner"`
+	Repo  string                 `json:"repo"`
+	Opts  *github.ProjectOptions `json:"options"`
 }
 
-func (r *createProjectRequest) Do(ctx context.Context, ghClient *github.Client) error {
-	log.Printf("create project (%q) on %s/%s", r.opts.Name, r.owner, r.repo)
-	_, _, err := ghClient.Repositories.CreateProject(ctx, r.owner, r.repo, r.opts)
+func (r *createProjectRequest) Do(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	logging.Infof("create project (%q) on %s/%s", r.Opts.Name, r.Owner, r.Repo)
+	project, _, err := ghClient.Repositories.CreateProject(ctx, r.Owner, r.Repo, r.Opts)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return &outcome{id: project.GetID(), number: project.GetNumber(), url: project.GetURL()}, nil
 }
 
-func (u *Usecase) buildProjectColumnRequests(ctx context.Context, sourceProject, targetProject *github.Project, sourceRepo, targetRepo *config.Repository, issueMapping map[int]int64) ([]request, error) {
+func (r *createProjectRequest) describe() *PlanStep {
+	return &PlanStep{
+		Action:  actionCreateProject,
+		Target:  fmt.Sprintf("%s/%s", r.Owner, r.Repo),
+		Summary: fmt.Sprintf("name=%q", r.Opts.Name),
+	}
+}
+
+// buildProjectColumnRequests builds requests to create columns and cards of the source project.
+// targetProject is nil if the project is going to be created.
+func (u *Usecase) buildProjectColumnRequests(ctx context.Context, sourceProject, targetProject *github.Project, sourceRepo, targetRepo *config.Repository) ([]request, error) {
 	sourceProjectColumns, err := u.sourceService.SlurpProjectColumns(ctx, sourceProject.GetID())
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch project columns on %s/%s id=%d: %w", sourceRepo.Owner, sourceRepo.Name, sourceProject.GetID(), err)
 	}
-	targetProjectColumns, err := u.targetService.SlurpProjectColumns(ctx, targetProject.GetID())
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch project columns on %s/%s id=%d: %w", targetRepo.Owner, targetRepo.Name, targetProject.GetID(), err)
+	targetProjectColumns := []*github.ProjectColumn{}
+	if targetProject != nil {
+		targetProjectColumns, err = u.targetService.SlurpProjectColumns(ctx, targetProject.GetID())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch project columns on %s/%s id=%d: %w", targetRepo.Owner, targetRepo.Name, targetProject.GetID(), err)
+		}
 	}
 
 	reqs := []request{}
@@ -84,18 +109,30 @@ func (u *Usecase) buildProjectColumnRequests(ctx context.Context, sourceProject,
 		switch op.Kind {
 		case domain.OpCreate:
 			req := &createProjectColumnRequest{
-				projectID: op.Project.GetID(),
-				opts: &github.ProjectColumnOptions{
+				origin:        origin{Source: projectColumnRef(sourceProject, op.ProjectColumn)},
+				ProjectID:     op.Project.GetID(),
+				SourceProject: sourceProject.GetName(),
+				Opts: &github.ProjectColumnOptions{
 					Name: op.ProjectColumn.GetName(),
 				},
 			}
 			reqs = append(reqs, req)
+			cardReqs, err := u.buildProjectCardRequests(ctx, sourceProject, op.ProjectColumn, nil, sourceRepo, targetRepo)
+			if err != nil {
+				return nil, err
+			}
+			logging.Debugf("%d card reqs", len(cardReqs))
+
+			reqs = append(reqs, cardReqs...)
 		case domain.OpUpdate:
-			cardReqs, err := u.buildProjectCardRequests(ctx, op.ProjectColumn, op.TargetProjectColumn, sourceRepo, targetRepo, issueMapping)
+			if err := u.mappings.Put(mapping.NewEntry(projectColumnRef(sourceProject, op.ProjectColumn), op.TargetProjectColumn.GetID(), 0, "")); err != nil {
+				return nil, err
+			}
+			cardReqs, err := u.buildProjectCardRequests(ctx, sourceProject, op.ProjectColumn, op.TargetProjectColumn, sourceRepo, targetRepo)
 			if err != nil {
 				return nil, err
 			}
-			log.Printf("%d card reqs", len(cardReqs))
+			logging.Debugf("%d card reqs", len(cardReqs))
 
 			reqs = append(reqs, cardReqs...)
 		default:
@@ -107,75 +144,166 @@ func (u *Usecase) buildProjectColumnRequests(ctx context.Context, sourceProject,
 }
 
 type createProjectColumnRequest struct {
-	projectID int64
-	opts      *github.ProjectColumnOptions
+	origin
+	ProjectID     int64                        `json:"projectID,omitempty"`
+	SourceProject string                       `json:"sourceProject,omitempty"` // resolved to ProjectID on apply if the project has not been created yet
+	Opts          *github.ProjectColumnOptions `json:"options"`
 }
 
-func (r *createProjectColumnRequest) Do(ctx context.Context, ghClient *github.Client) error {
-	log.Printf("create project column (%q) on project.ID=%d", r.opts.Name, r.projectID)
-	_, _, err := ghClient.Projects.CreateProjectColumn(ctx, r.projectID, r.opts)
+func (r *createProjectColumnRequest) resolve(store *mapping.Store) error {
+	if r.ProjectID != 0 {
+		return nil
+	}
+	entry, err := lookupMapping(store, mapping.KindProject, r.SourceProject)
 	if err != nil {
 		return err
 	}
+	r.ProjectID = entry.TargetID
 	return nil
 }
 
+func (r *createProjectColumnRequest) Do(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	logging.Infof("create project column (%q) on project.ID=%d", r.Opts.Name, r.ProjectID)
+	column, _, err := ghClient.Projects.CreateProjectColumn(ctx, r.ProjectID, r.Opts)
+	if err != nil {
+		return nil, err
+	}
+	return &outcome{id: column.GetID()}, nil
+}
+
+func (r *createProjectColumnRequest) describe() *PlanStep {
+	target := fmt.Sprintf("project.ID=%d", r.ProjectID)
+	if r.ProjectID == 0 {
+		target = fmt.Sprintf("project=%q", r.SourceProject)
+	}
+	return &PlanStep{
+		Action:  actionCreateProjectColumn,
+		Target:  target,
+		Summary: fmt.Sprintf("name=%q", r.Opts.Name),
+	}
+}
+
+const (
+	contentTypeIssue       = "Issue"
+	contentTypePullRequest = "PullRequest"
+)
+
+// cardContentType tells the type of the migrated issue; IDs of pull requests recreated as pull requests are not ones of issues.
+func cardContentType(entry *mapping.Entry) string {
+	if entry.TargetType == contentTypePullRequest {
+		return contentTypePullRequest
+	}
+	return contentTypeIssue
+}
+
 type createProjectCardRequest struct {
-	columnID int64
-	opts     *github.ProjectCardOptions
+	origin
+	ColumnID          int64                      `json:"columnID,omitempty"`
+	SourceColumn      string                     `json:"sourceColumn,omitempty"`      // resolved to ColumnID on apply if the column has not been created yet
+	SourceIssueNumber int                        `json:"sourceIssueNumber,omitempty"` // resolved to the content on apply if the issue has not been migrated yet
+	Opts              *github.ProjectCardOptions `json:"options"`
 }
 
-func (r *createProjectCardRequest) Do(ctx context.Context, ghClient *github.Client) error {
-	log.Printf("create project card (opts=%#v) on projectColumn.ID=%d", r.opts, r.columnID)
-	_, _, err := ghClient.Projects.CreateProjectCard(ctx, r.columnID, r.opts)
-	if err != nil {
-		return err
+func (r *createProjectCardRequest) resolve(store *mapping.Store) error {
+	if r.ColumnID == 0 {
+		entry, err := lookupMapping(store, mapping.KindProjectColumn, r.SourceColumn)
+		if err != nil {
+			return err
+		}
+		r.ColumnID = entry.TargetID
+	}
+	if r.Opts.ContentID == 0 && r.SourceIssueNumber != 0 {
+		entry, err := lookupMapping(store, mapping.KindIssue, strconv.Itoa(r.SourceIssueNumber))
+		if err != nil {
+			return err
+		}
+		r.Opts.ContentID = entry.TargetID
+		r.Opts.ContentType = cardContentType(entry)
 	}
 	return nil
 }
 
-func (u *Usecase) buildProjectCardRequests(ctx context.Context, sourceColumn, targetColumn *github.ProjectColumn, sourceRepo, targetRepo *config.Repository, issueMapping map[int]int64) ([]request, error) {
+func (r *createProjectCardRequest) Do(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	logging.Infof("create project card (opts=%#v) on projectColumn.ID=%d", r.Opts, r.ColumnID)
+	card, _, err := ghClient.Projects.CreateProjectCard(ctx, r.ColumnID, r.Opts)
+	if err != nil {
+		return nil, err
+	}
+	return &outcome{id: card.GetID(), url: card.GetURL()}, nil
+}
+
+func (r *createProjectCardRequest) describe() *PlanStep {
+	step := &PlanStep{
+		Action: actionCreateProjectCard,
+		Target: fmt.Sprintf("projectColumn.ID=%d", r.ColumnID),
+	}
+	if r.ColumnID == 0 {
+		step.Target = fmt.Sprintf("projectColumn=%q", r.SourceColumn)
+	}
+	switch {
+	case r.Opts.Note != "":
+		step.Summary = fmt.Sprintf("note=%q", r.Opts.Note)
+	case r.Opts.ContentID == 0:
+		step.Summary = fmt.Sprintf("contentType=Issue sourceIssue=#%d", r.SourceIssueNumber)
+	default:
+		step.Summary = fmt.Sprintf("contentType=%s contentID=%d", r.Opts.ContentType, r.Opts.ContentID)
+	}
+	return step
+}
+
+// buildProjectCardRequests builds requests to create cards of the source column.
+// targetColumn is nil if the column is going to be created.
+func (u *Usecase) buildProjectCardRequests(ctx context.Context, sourceProject *github.Project, sourceColumn, targetColumn *github.ProjectColumn, sourceRepo, targetRepo *config.Repository) ([]request, error) {
 	sourceCards, err := u.sourceService.SlurpProjectCards(ctx, sourceColumn.GetID())
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch project cards on %s/%s columnId=%d: %w", sourceRepo.Owner, sourceRepo.Name, sourceColumn.GetID(), err)
 	}
-	targetCards, err := u.targetService.SlurpProjectCards(ctx, targetColumn.GetID())
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch project cards on %s/%s columnId=%d: %w", targetRepo.Owner, targetRepo.Name, targetColumn.GetID(), err)
+	targetCards := []*github.ProjectCard{}
+	if targetColumn != nil {
+		targetCards, err = u.targetService.SlurpProjectCards(ctx, targetColumn.GetID())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch project cards on %s/%s columnId=%d: %w", targetRepo.Owner, targetRepo.Name, targetColumn.GetID(), err)
+		}
 	}
-	log.Printf("%d source cards on column %q %d", len(sourceCards), sourceColumn.GetName(), sourceColumn.GetID())
-	log.Printf("%d target cards on column %q %d", len(targetCards), targetColumn.GetName(), targetColumn.GetID())
+	logging.Debugf("%d source cards on column %q %d", len(sourceCards), sourceColumn.GetName(), sourceColumn.GetID())
+	logging.Debugf("%d target cards on column %q %d", len(targetCards), targetColumn.GetName(), targetColumn.GetID())
 
 	reqs := []request{}
 	for _, op := range domain.NewProjectCardOpsList(sourceCards, targetCards, sourceColumn, targetColumn) {
 		switch op.Kind {
 		case domain.OpCreate:
-			opts := &github.ProjectCardOptions{Note: op.ProjectCard.GetNote()}
-			if opts.Note == "" {
+			ref := projectCardRef(op.ProjectCard)
+			if _, ok := u.mappings.Lookup(ref.Kind, ref.Key); ok {
+				logging.Debugf("card (id=%d) already migrated", op.ProjectCard.GetID())
+				continue
+			}
+			req := &createProjectCardRequest{
+				origin:       origin{Source: ref},
+				ColumnID:     op.ProjectColumn.GetID(),
+				SourceColumn: projectColumnKey(sourceProject, sourceColumn),
+				Opts:         &github.ProjectCardOptions{Note: op.ProjectCard.GetNote()},
+			}
+			if req.Opts.Note == "" {
 				issues := "/issues/"
 				contentURL := op.ProjectCard.GetContentURL() // e.g. https://api.github.com/repos/api-playground/projects-test/issues/3
 				idx := strings.Index(contentURL, issues)
 				if idx == -1 {
-					log.Printf("! card (id=%d) invalid contentURL: %q", op.ProjectCard.GetID(), contentURL)
+					logging.Warnf("card (id=%d) invalid contentURL: %q", op.ProjectCard.GetID(), contentURL)
 					continue
 				}
 				offset := idx + len(issues)
 				repr := contentURL[offset:]
 				issueNum, err := strconv.Atoi(repr)
 				if err != nil {
-					log.Printf("! card (id=%d) invalid contentURL: %q", op.ProjectCard.GetID(), contentURL)
+					logging.Warnf("card (id=%d) invalid contentURL: %q", op.ProjectCard.GetID(), contentURL)
 					continue
 				}
-				id, ok := issueMapping[issueNum]
-				if !ok {
-					return nil, fmt.Errorf("no issue mapping found for number=%d", issueNum)
+				if entry, ok := u.mappings.Lookup(mapping.KindIssue, repr); ok {
+					req.Opts.ContentID = entry.TargetID
+					req.Opts.ContentType = cardContentType(entry)
+				} else {
+					req.SourceIssueNumber = issueNum
 				}
-				opts.ContentID = id
-				opts.ContentType = "Issue"
-			}
-			req := &createProjectCardRequest{
-				columnID: op.ProjectColumn.GetID(),
-				opts:     opts,
 			}
 			reqs = append(reqs, req)
 		default:
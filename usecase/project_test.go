@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aereal/migrate-gh-repo/mapping"
+	"github.com/google/go-github/github"
+)
+
+func TestCreateProjectCardRequest_resolve(t *testing.T) {
+	store := mapping.NewMemoryStore()
+	store.Put(&mapping.Entry{Kind: mapping.KindProjectColumn, SourceKey: "Roadmap/Todo", TargetID: 20})
+	store.Put(&mapping.Entry{Kind: mapping.KindIssue, SourceKey: "3", TargetID: 30, TargetNumber: 3})
+	store.Put(&mapping.Entry{Kind: mapping.KindIssue, SourceKey: "5", TargetID: 50, TargetNumber: 5, TargetType: "PullRequest"})
+
+	cases := []struct {
+		name    string
+		req     *createProjectCardRequest
+		want    *createProjectCardRequest
+		wantErr bool
+	}{
+		{
+			name: "note on created column",
+			req:  &createProjectCardRequest{SourceColumn: "Roadmap/Todo", Opts: &github.ProjectCardOptions{Note: "memo"}},
+			want: &createProjectCardRequest{ColumnID: 20, SourceColumn: "Roadmap/Todo", Opts: &github.ProjectCardOptions{Note: "memo"}},
+		},
+		{
+			name: "migrated issue",
+			req:  &createProjectCardRequest{ColumnID: 10, SourceIssueNumber: 3, Opts: &github.ProjectCardOptions{}},
+			want: &createProjectCardRequest{ColumnID: 10, SourceIssueNumber: 3, Opts: &github.ProjectCardOptions{ContentID: 30, ContentType: "Issue"}},
+		},
+		{
+			name: "recreated pull request",
+			req:  &createProjectCardRequest{ColumnID: 10, SourceIssueNumber: 5, Opts: &github.ProjectCardOptions{}},
+			want: &createProjectCardRequest{ColumnID: 10, SourceIssueNumber: 5, Opts: &github.ProjectCardOptions{ContentID: 50, ContentType: "PullRequest"}},
+		},
+		{
+			name: "already resolved",
+			req:  &createProjectCardRequest{ColumnID: 10, Opts: &github.ProjectCardOptions{ContentID: 40, ContentType: "Issue"}},
+			want: &createProjectCardRequest{ColumnID: 10, Opts: &github.ProjectCardOptions{ContentID: 40, ContentType: "Issue"}},
+		},
+		{
+			name:    "column not created",
+			req:     &createProjectCardRequest{SourceColumn: "Roadmap/Done", Opts: &github.ProjectCardOptions{Note: "memo"}},
+			wantErr: true,
+		},
+		{
+			name:    "issue not migrated",
+			req:     &createProjectCardRequest{ColumnID: 10, SourceIssueNumber: 4, Opts: &github.ProjectCardOptions{}},
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.req.resolve(store)
+			if c.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(c.req, c.want) {
+				t.Errorf("got %#v, want %#v", c.req, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aereal/migrate-gh-repo/config"
+	"github.com/aereal/migrate-gh-repo/domain"
+	"github.com/aereal/migrate-gh-repo/logging"
+	"github.com/aereal/migrate-gh-repo/mapping"
+	"github.com/google/go-github/github"
+)
+
+// newPullRequestRequests recreates the open pull request as a pull request on the target.
+// The issue to be created instead is kept as the fallback for the case the branches are missing on the target.
+func (u *Usecase) newPullRequestRequests(ctx context.Context, sourceRepo, targetRepo *config.Repository, issue *github.Issue, createReq *createIssueRequest) ([]request, error) {
+	pr, err := u.sourceService.GetPullRequest(ctx, sourceRepo.Owner, sourceRepo.Name, issue.GetNumber())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pull request #%d from source repository: %w", issue.GetNumber(), err)
+	}
+
+	fallbackBody := fmt.Sprintf("%s\n\n%s", createReq.IssueReq.GetBody(), diffSummary(pr))
+	fallbackIssueReq := *createReq.IssueReq
+	fallbackIssueReq.Body = &fallbackBody
+	fallback := &createIssueRequest{
+		Owner:           createReq.Owner,
+		Repo:            createReq.Repo,
+		IssueReq:        &fallbackIssueReq,
+		ExpectedNumber:  createReq.ExpectedNumber,
+		SourceMilestone: createReq.SourceMilestone,
+	}
+	if headRepo := pr.GetHead().GetRepo().GetFullName(); headRepo != fmt.Sprintf("%s/%s", sourceRepo.Owner, sourceRepo.Name) {
+		logging.Infof("pull request #%d comes from another repository (%q); migrated as an issue", issue.GetNumber(), headRepo)
+		fallback.origin = createReq.origin
+		return []request{fallback}, nil
+	}
+
+	prReq := &createPullRequestRequest{
+		origin: createReq.origin,
+		Owner:  targetRepo.Owner,
+		Repo:   targetRepo.Name,
+		PullRequest: &github.NewPullRequest{
+			Title: createReq.IssueReq.Title,
+			Head:  pr.GetHead().Ref,
+			Base:  pr.GetBase().Ref,
+			Body:  createReq.IssueReq.Body,
+		},
+		ExpectedNumber: createReq.ExpectedNumber,
+		Fallback:       fallback,
+	}
+	// pull requests cannot be created with labels, assignees and milestone
+	updateReq := &updateIssueRequest{
+		Owner:             targetRepo.Owner,
+		Repo:              targetRepo.Name,
+		IssueNumber:       createReq.ExpectedNumber,
+		SourceIssueNumber: issue.GetNumber(),
+		IssueReq: &github.IssueRequest{
+			Labels:    createReq.IssueReq.Labels,
+			Assignees: createReq.IssueReq.Assignees,
+			Milestone: createReq.IssueReq.Milestone,
+		},
+		SourceMilestone: createReq.SourceMilestone,
+	}
+	return []request{prReq, updateReq}, nil
+}
+
+func diffSummary(pr *github.PullRequest) string {
+	return fmt.Sprintf(
+		"This pull request could not be recreated. It proposed to merge `%s` into `%s` with %d commits changing %d files (+%d -%d): %s/files",
+		pr.GetHead().GetRef(),
+		pr.GetBase().GetRef(),
+		pr.GetCommits(),
+		pr.GetChangedFiles(),
+		pr.GetAdditions(),
+		pr.GetDeletions(),
+		pr.GetHTMLURL(),
+	)
+}
+
+type createPullRequestRequest struct {
+	origin
+	Owner          string                 `json:"owner"`
+	Repo           string                 `json:"repo"`
+	PullRequest    *github.NewPullRequest `json:"pullRequest"`
+	ExpectedNumber int                    `json:"expectedNumber,omitempty"`
+	Fallback       *createIssueRequest    `json:"fallback"`
+}
+
+func (r *createPullRequestRequest) resolve(store *mapping.Store) error {
+	return r.Fallback.resolve(store)
+}
+
+func (r *createPullRequestRequest) resolveReferences(references *domain.ReferenceRewriter) {
+	if r.PullRequest.Body != nil {
+		body := references.ResolvePending(r.PullRequest.GetBody())
+		r.PullRequest.Body = &body
+	}
+	r.Fallback.resolveReferences(references)
+}
+
+func (r *createPullRequestRequest) Do(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	exist, err := branchesExist(ctx, ghClient, r.Owner, r.Repo, r.PullRequest.GetHead(), r.PullRequest.GetBase())
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		logging.Infof("branches %q or %q not found on %s/%s; create an issue instead of the pull request", r.PullRequest.GetHead(), r.PullRequest.GetBase(), r.Owner, r.Repo)
+		return r.Fallback.Do(ctx, ghClient)
+	}
+
+	logging.Infof("create pull request on %s/%s: title=%q head=%q base=%q", r.Owner, r.Repo, r.PullRequest.GetTitle(), r.PullRequest.GetHead(), r.PullRequest.GetBase())
+	pr, _, err := ghClient.PullRequests.Create(ctx, r.Owner, r.Repo, r.PullRequest)
+	if err != nil {
+		if hasStatus(err, http.StatusUnprocessableEntity) {
+			// e.g. no commits between the branches
+			logging.Warnf("cannot create pull request on %s/%s: %v; create an issue instead", r.Owner, r.Repo, err)
+			return r.Fallback.Do(ctx, ghClient)
+		}
+		return nil, err
+	}
+	if r.ExpectedNumber != 0 && pr.GetNumber() != r.ExpectedNumber {
+		return nil, fmt.Errorf("pull request created as #%d but #%d expected; issue numbers no longer match the source repository", pr.GetNumber(), r.ExpectedNumber)
+	}
+	return &outcome{id: pr.GetID(), number: pr.GetNumber(), url: pr.GetHTMLURL(), targetType: contentTypePullRequest}, nil
+}
+
+func (r *createPullRequestRequest) findCreated(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	// the fallback issue has the same marker as the pull request
+	issue, err := findIssueByMarker(ctx, ghClient, r.Owner, r.Repo, r.PullRequest.GetBody())
+	if err != nil || issue == nil {
+		return nil, err
+	}
+	if r.ExpectedNumber != 0 && issue.GetNumber() != r.ExpectedNumber {
+		return nil, fmt.Errorf("pull request created as #%d but #%d expected; issue numbers no longer match the source repository", issue.GetNumber(), r.ExpectedNumber)
+	}
+	out := &outcome{id: issue.GetID(), number: issue.GetNumber(), url: issue.GetHTMLURL()}
+	if issue.IsPullRequest() {
+		out.targetType = contentTypePullRequest
+	}
+	return out, nil
+}
+
+func (r *createPullRequestRequest) describe() *PlanStep {
+	target := fmt.Sprintf("%s/%s", r.Owner, r.Repo)
+	if r.ExpectedNumber != 0 {
+		target = fmt.Sprintf("%s#%d", target, r.ExpectedNumber)
+	}
+	return &PlanStep{
+		Action:  actionCreatePullRequest,
+		Target:  target,
+		Summary: fmt.Sprintf("title=%q head=%q base=%q (an issue if branches are missing)", r.PullRequest.GetTitle(), r.PullRequest.GetHead(), r.PullRequest.GetBase()),
+	}
+}
+
+func branchesExist(ctx context.Context, ghClient *github.Client, owner, repo string, branches ...string) (bool, error) {
+	for _, branch := range branches {
+		_, _, err := ghClient.Repositories.GetBranch(ctx, owner, repo, branch)
+		if hasStatus(err, http.StatusNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to get branch %q on %s/%s: %w", branch, owner, repo, err)
+		}
+	}
+	return true, nil
+}
+
+// hasStatus tells whether the error is the response from the API with the status code.
+func hasStatus(err error, code int) bool {
+	var errResp *github.ErrorResponse
+	return errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == code
+}
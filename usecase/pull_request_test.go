@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/github"
+)
+
+// pullRequestServer serves branches and accepts creations of pull requests and issues on aereal/dest.
+func pullRequestServer(branches map[string]int, pullStatus int, created *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/repos/aereal/dest/branches/"):
+			status, ok := branches[strings.TrimPrefix(r.URL.Path, "/repos/aereal/dest/branches/")]
+			if !ok {
+				status = http.StatusNotFound
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(`{}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/aereal/dest/pulls":
+			*created = append(*created, "pull")
+			if pullStatus != http.StatusCreated {
+				w.WriteHeader(pullStatus)
+				w.Write([]byte(`{"message":"Validation Failed"}`))
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":500,"number":7,"html_url":"https://github.com/aereal/dest/pull/7"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/aereal/dest/issues":
+			*created = append(*created, "issue")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":600,"number":7,"html_url":"https://github.com/aereal/dest/issues/7"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestCreatePullRequestRequest_Do(t *testing.T) {
+	tests := []struct {
+		name        string
+		branches    map[string]int
+		pullStatus  int
+		wantErr     bool
+		wantCreated string
+		want        *outcome
+	}{
+		{
+			name:        "branches exist",
+			branches:    map[string]int{"feature": http.StatusOK, "master": http.StatusOK},
+			pullStatus:  http.StatusCreated,
+			wantCreated: "pull",
+			want:        &outcome{id: 500, number: 7, url: "https://github.com/aereal/dest/pull/7", targetType: contentTypePullRequest},
+		},
+		{
+			name:        "head branch missing",
+			branches:    map[string]int{"master": http.StatusOK},
+			pullStatus:  http.StatusCreated,
+			wantCreated: "issue",
+			want:        &outcome{id: 600, number: 7, url: "https://github.com/aereal/dest/issues/7"},
+		},
+		{
+			name:        "pull request rejected",
+			branches:    map[string]int{"feature": http.StatusOK, "master": http.StatusOK},
+			pullStatus:  http.StatusUnprocessableEntity,
+			wantCreated: "pull,issue",
+			want:        &outcome{id: 600, number: 7, url: "https://github.com/aereal/dest/issues/7"},
+		},
+		{
+			name:       "pull request failed",
+			branches:   map[string]int{"feature": http.StatusOK, "master": http.StatusOK},
+			pullStatus: http.StatusInternalServerError,
+			wantErr:    true,
+		},
+		{
+			name:       "branch unknown",
+			branches:   map[string]int{"feature": http.StatusInternalServerError},
+			pullStatus: http.StatusCreated,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created := []string{}
+			srv := pullRequestServer(tt.branches, tt.pullStatus, &created)
+			defer srv.Close()
+			u := newTestUsecase(t, srv)
+
+			r := &createPullRequestRequest{
+				Owner:       "aereal",
+				Repo:        "dest",
+				PullRequest: &github.NewPullRequest{Title: strRef("feature"), Head: strRef("feature"), Base: strRef("master")},
+				Fallback:    &createIssueRequest{Owner: "aereal", Repo: "dest", IssueReq: &github.IssueRequest{Title: strRef("feature")}},
+			}
+			got, err := r.Do(context.Background(), u.targetClient)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != *tt.want {
+				t.Errorf("outcome = %#v, want %#v", got, tt.want)
+			}
+			if strings.Join(created, ",") != tt.wantCreated {
+				t.Errorf("created = %v, want %s", created, tt.wantCreated)
+			}
+		})
+	}
+}
+
+func TestHasStatus(t *testing.T) {
+	if hasStatus(&github.ErrorResponse{}, http.StatusNotFound) {
+		t.Error("hasStatus() must be false for the error without response")
+	}
+	if !hasStatus(&github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}, http.StatusNotFound) {
+		t.Error("hasStatus() must be true for 404")
+	}
+	if hasStatus(nil, http.StatusNotFound) {
+		t.Error("hasStatus() must be false for nil")
+	}
+}
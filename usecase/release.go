@@ -0,0 +1,215 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/aereal/migrate-gh-repo/config"
+	"github.com/aereal/migrate-gh-repo/domain"
+	"github.com/aereal/migrate-gh-repo/logging"
+	"github.com/aereal/migrate-gh-repo/mapping"
+	"github.com/google/go-github/github"
+)
+
+// sourceReading is implemented by requests reading contents of the source repository on apply.
+type sourceReading interface {
+	setSourceClient(ghClient *github.Client, downloadClient *http.Client)
+}
+
+func (u *Usecase) buildReleaseRequests(ctx context.Context, source, target *config.Repository) ([]request, error) {
+	sourceReleases, err := u.sourceService.SlurpReleases(ctx, source.Owner, source.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch releases from source repository: %w", err)
+	}
+	targetReleases, err := u.targetService.SlurpReleases(ctx, target.Owner, target.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch releases from target repository: %w", err)
+	}
+
+	reqs := []request{}
+	for _, op := range domain.NewReleaseOpsList(sourceReleases, targetReleases) {
+		switch op.Kind {
+		case domain.OpCreate:
+			reqs = append(reqs, &createReleaseRequest{
+				origin: origin{Source: releaseRef(op.Release)},
+				Owner:  target.Owner,
+				Repo:   target.Name,
+				Release: &github.RepositoryRelease{
+					TagName:         op.Release.TagName,
+					TargetCommitish: op.Release.TargetCommitish,
+					Name:            op.Release.Name,
+					Body:            github.String(u.rewriteBody(op.Release.GetBody())),
+					Draft:           op.Release.Draft,
+					Prerelease:      op.Release.Prerelease,
+				},
+			})
+		case domain.OpUpdate:
+			if err := u.mappings.Put(mapping.NewEntry(releaseRef(op.Release), op.TargetRelease.GetID(), 0, op.TargetRelease.GetHTMLURL())); err != nil {
+				return nil, err
+			}
+		default:
+			// no-op
+		}
+		for _, asset := range domain.MissingReleaseAssets(op.Release, op.TargetRelease) {
+			asset := asset
+			reqs = append(reqs, &uploadReleaseAssetRequest{
+				origin:      origin{Source: releaseAssetRef(op.Release, &asset)},
+				Owner:       target.Owner,
+				Repo:        target.Name,
+				ReleaseID:   op.TargetRelease.GetID(),
+				SourceTag:   op.Release.GetTagName(),
+				SourceOwner: source.Owner,
+				SourceRepo:  source.Name,
+				AssetID:     asset.GetID(),
+				Name:        asset.GetName(),
+			})
+		}
+	}
+	return reqs, nil
+}
+
+type createReleaseRequest struct {
+	origin
+	Owner   string                    `json:"owner"`
+	Repo    string                    `json:"repo"`
+	Release *github.RepositoryRelease `json:"release"`
+}
+
+func (r *createReleaseRequest) resolveReferences(references *domain.ReferenceRewriter) {
+	if r.Release.Body != nil {
+		body := references.ResolvePending(r.Release.GetBody())
+		r.Release.Body = &body
+	}
+}
+
+func (r *createReleaseRequest) Do(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	logging.Infof("create release (%q) on %s/%s", r.Release.GetTagName(), r.Owner, r.Repo)
+	release, _, err := ghClient.Repositories.CreateRelease(ctx, r.Owner, r.Repo, r.Release)
+	if err != nil {
+		return nil, err
+	}
+	return &outcome{id: release.GetID(), url: release.GetHTMLURL()}, nil
+}
+
+func (r *createReleaseRequest) describe() *PlanStep {
+	return &PlanStep{
+		Action:  actionCreateRelease,
+		Target:  fmt.Sprintf("%s/%s", r.Owner, r.Repo),
+		Summary: fmt.Sprintf("tag=%q name=%q draft=%v prerelease=%v", r.Release.GetTagName(), r.Release.GetName(), r.Release.GetDraft(), r.Release.GetPrerelease()),
+	}
+}
+
+// uploadReleaseAssetRequest downloads the asset from the source repository and uploads it to the target release.
+type uploadReleaseAssetRequest struct {
+	origin
+	Owner       string `json:"owner"`
+	Repo        string `json:"repo"`
+	ReleaseID   int64  `json:"releaseID,omitempty"`
+	SourceTag   string `json:"sourceTag"` // resolved to ReleaseID on apply if the release has not been created yet
+	SourceOwner string `json:"sourceOwner"`
+	SourceRepo  string `json:"sourceRepo"`
+	AssetID     int64  `json:"assetID"`
+	Name        string `json:"name"`
+
+	sourceClient   *github.Client
+	downloadClient *http.Client
+}
+
+func (r *uploadReleaseAssetRequest) setSourceClient(ghClient *github.Client, downloadClient *http.Client) {
+	r.sourceClient = ghClient
+	r.downloadClient = downloadClient
+}
+
+func (r *uploadReleaseAssetRequest) resolve(store *mapping.Store) error {
+	if r.ReleaseID != 0 {
+		return nil
+	}
+	entry, err := lookupMapping(store, mapping.KindRelease, r.SourceTag)
+	if err != nil {
+		return err
+	}
+	r.ReleaseID = entry.TargetID
+	return nil
+}
+
+func (r *uploadReleaseAssetRequest) Do(ctx context.Context, ghClient *github.Client) (*outcome, error) {
+	if r.sourceClient == nil {
+		return nil, fmt.Errorf("no client for the source repository to download the asset")
+	}
+	f, err := r.download(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	logging.Infof("upload release asset (%q) to release.ID=%d on %s/%s", r.Name, r.ReleaseID, r.Owner, r.Repo)
+	asset, _, err := ghClient.Repositories.UploadReleaseAsset(ctx, r.Owner, r.Repo, r.ReleaseID, &github.UploadOptions{Name: r.Name}, f)
+	if err != nil {
+		return nil, err
+	}
+	return &outcome{id: asset.GetID(), url: asset.GetBrowserDownloadURL()}, nil
+}
+
+// download saves the asset into a temporary file; its extension is kept because the media type of the upload is derived from it.
+func (r *uploadReleaseAssetRequest) download(ctx context.Context) (*os.File, error) {
+	logging.Infof("download release asset (%q) from %s/%s", r.Name, r.SourceOwner, r.SourceRepo)
+	rc, redirectURL, err := r.sourceClient.Repositories.DownloadReleaseAsset(ctx, r.SourceOwner, r.SourceRepo, r.AssetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download release asset (id=%d): %w", r.AssetID, err)
+	}
+	if rc == nil {
+		// the redirected URL is signed, so it must be fetched without credentials of the API
+		req, err := http.NewRequest(http.MethodGet, redirectURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		downloadClient := r.downloadClient
+		if downloadClient == nil {
+			downloadClient = http.DefaultClient
+		}
+		resp, err := downloadClient.Do(req.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to download release asset (id=%d): %w", r.AssetID, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to download release asset (id=%d): unexpected status %s", r.AssetID, resp.Status)
+		}
+		rc = resp.Body
+	}
+	defer rc.Close()
+
+	f, err := ioutil.TempFile("", "migrate-gh-repo-asset-*"+filepath.Ext(r.Name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	if _, err := io.Copy(f, rc); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to save release asset (id=%d): %w", r.AssetID, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return f, nil
+}
+
+func (r *uploadReleaseAssetRequest) describe() *PlanStep {
+	target := fmt.Sprintf("%s/%s release.ID=%d", r.Owner, r.Repo, r.ReleaseID)
+	if r.ReleaseID == 0 {
+		target = fmt.Sprintf("%s/%s release=%q", r.Owner, r.Repo, r.SourceTag)
+	}
+	return &PlanStep{
+		Action:  actionUploadReleaseAsset,
+		Target:  target,
+		Summary: fmt.Sprintf("name=%q", r.Name),
+	}
+}
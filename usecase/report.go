@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+type Failure struct {
+	Step    int    `json:"step"`
+	Action  string `json:"action"`
+	Target  string `json:"target"`
+	Summary string `json:"summary"`
+	Error   string `json:"error"`
+}
+
+// FailureReport is returned by Apply when some requests failed but the migration continued.
+// Total counts requests skipped as completed in the journal as well.
+type FailureReport struct {
+	Total     int        `json:"total"`
+	Succeeded int        `json:"succeeded"`
+	Skipped   int        `json:"skipped"`
+	Failures  []*Failure `json:"failures"`
+}
+
+func (r *FailureReport) Error() string {
+	return fmt.Sprintf("%d of %d requests failed", len(r.Failures), r.Total)
+}
+
+func (r *FailureReport) add(stepNum int, step *PlanStep, err error) {
+	r.Failures = append(r.Failures, &Failure{
+		Step:    stepNum,
+		Action:  step.Action,
+		Target:  step.Target,
+		Summary: step.Summary,
+		Error:   err.Error(),
+	})
+}
+
+// AddFailure records the failure of the phase out of the plan (e.g. wiki), which is shown without the step number.
+func (r *FailureReport) AddFailure(action, target string, err error) {
+	r.Total++
+	r.Failures = append(r.Failures, &Failure{Action: action, Target: target, Error: err.Error()})
+}
+
+func (r *FailureReport) WriteTable(w io.Writer) error {
+	if r.Skipped > 0 {
+		fmt.Fprintf(w, "%d requests succeeded, %d requests skipped as already completed, %d requests failed:\n", r.Succeeded, r.Skipped, len(r.Failures))
+	} else {
+		fmt.Fprintf(w, "%d requests succeeded, %d requests failed:\n", r.Succeeded, len(r.Failures))
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "#\tACTION\tTARGET\tSUMMARY\tERROR\n")
+	for _, f := range r.Failures {
+		step := "-"
+		if f.Step != 0 {
+			step = strconv.Itoa(f.Step)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", step, f.Action, f.Target, truncateSummary(f.Summary), f.Error)
+	}
+	return tw.Flush()
+}
+
+func (r *FailureReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aereal/migrate-gh-repo/journal"
+	"github.com/google/go-github/github"
+)
+
+// validatingLabelServer creates labels counting requests, and rejects ones named "invalid" with 422.
+func validatingLabelServer(calls *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		label := &github.Label{}
+		if err := json.NewDecoder(r.Body).Decode(label); err != nil || label.GetName() == "invalid" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"message":"Validation Failed"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(label)
+	}))
+}
+
+func labelPlan(t *testing.T) *Plan {
+	return newTestPlan(t,
+		&createLabelRequest{Owner: "aereal", Repo: "dest", Label: &github.Label{Name: strRef("bug")}},
+		&createLabelRequest{Owner: "aereal", Repo: "dest", Label: &github.Label{Name: strRef("invalid")}},
+		&createLabelRequest{Owner: "aereal", Repo: "dest", Label: &github.Label{Name: strRef("feature")}},
+	)
+}
+
+func TestUsecase_Apply_stopsAtFailure(t *testing.T) {
+	calls := 0
+	srv := validatingLabelServer(&calls)
+	defer srv.Close()
+	u := newTestUsecase(t, srv)
+
+	err := u.Apply(context.Background(), labelPlan(t))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	var report *FailureReport
+	if errors.As(err, &report) {
+		t.Errorf("unexpected failure report: %v", report)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestUsecase_Apply_continueOnError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	j, err := journal.Open(filepath.Join(dir, "journal.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	calls := 0
+	srv := validatingLabelServer(&calls)
+	defer srv.Close()
+
+	u := newTestUsecase(t, srv, WithContinueOnError(true), WithJournal(j))
+	err = u.Apply(context.Background(), labelPlan(t))
+	// main exits with non-zero status for any error including the report
+	var report *FailureReport
+	if !errors.As(err, &report) {
+		t.Fatalf("Apply() error = %v, want *FailureReport", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+	if report.Total != 3 || report.Succeeded != 2 || report.Skipped != 0 || len(report.Failures) != 1 {
+		t.Errorf("report = %+v", report)
+	}
+	if f := report.Failures[0]; f.Step != 2 || f.Action != actionCreateLabel || !strings.Contains(f.Error, "422") {
+		t.Errorf("failure = %+v", f)
+	}
+	if got, want := report.Error(), "1 of 3 requests failed"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	// resume: completed requests are skipped and counted
+	calls = 0
+	u = newTestUsecase(t, srv, WithContinueOnError(true), WithJournal(j))
+	err = u.Apply(context.Background(), labelPlan(t))
+	if !errors.As(err, &report) {
+		t.Fatalf("Apply() error = %v, want *FailureReport", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+	if report.Total != 3 || report.Succeeded != 0 || report.Skipped != 2 || len(report.Failures) != 1 {
+		t.Errorf("report = %+v", report)
+	}
+	buf := &bytes.Buffer{}
+	if err := report.WriteTable(buf); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.SplitN(buf.String(), "\n", 2)[0], "0 requests succeeded, 2 requests skipped as already completed, 1 requests failed:"; got != want {
+		t.Errorf("WriteTable() header = %q, want %q", got, want)
+	}
+}
+
+func TestFailureReport_AddFailure(t *testing.T) {
+	report := &FailureReport{Total: 2, Succeeded: 1, Failures: []*Failure{{Step: 2, Action: actionCreateLabel, Target: "aereal/dest", Error: "422"}}}
+	report.AddFailure("migrate_wiki", "aereal/dest.wiki", errors.New("push rejected"))
+
+	if got, want := report.Error(), "2 of 3 requests failed"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	buf := &bytes.Buffer{}
+	if err := report.WriteTable(buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if got := lines[len(lines)-1]; !strings.HasPrefix(got, "-  migrate_wiki") || !strings.HasSuffix(got, "push rejected") {
+		t.Errorf("last line = %q", got)
+	}
+}
+
+func TestUsecase_LastReport(t *testing.T) {
+	calls := 0
+	srv := validatingLabelServer(&calls)
+	defer srv.Close()
+	u := newTestUsecase(t, srv, WithContinueOnError(true))
+	if u.LastReport() != nil {
+		t.Error("LastReport() must be nil before Apply")
+	}
+
+	plan := newTestPlan(t, &createLabelRequest{Owner: "aereal", Repo: "dest", Label: &github.Label{Name: strRef("bug")}})
+	if err := u.Apply(context.Background(), plan); err != nil {
+		t.Fatal(err)
+	}
+	report := u.LastReport()
+	if report == nil || report.Total != 1 || report.Succeeded != 1 || len(report.Failures) != 0 {
+		t.Fatalf("LastReport() = %+v", report)
+	}
+	report.AddFailure("migrate_wiki", "aereal/dest.wiki", errors.New("push rejected"))
+	if got, want := report.Error(), "1 of 2 requests failed"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aereal/migrate-gh-repo/config"
+	"github.com/aereal/migrate-gh-repo/domain"
+	"github.com/google/go-github/github"
+)
+
+// buildReviewRequests builds requests to create comments on the migrated pull request from its reviews and review threads.
+// targetNumber is the number of the pull request on the target, or 0 if it is resolved on apply.
+func (u *Usecase) buildReviewRequests(ctx context.Context, source, target *config.Repository, pr *github.Issue, targetNumber int, targetComments []*github.IssueComment) ([]*timedRequest, error) {
+	reviews, err := u.sourceService.SlurpPullRequestReviews(ctx, source.Owner, source.Name, pr.GetNumber())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reviews of #%d from source repository: %w", pr.GetNumber(), err)
+	}
+	reviewComments, err := u.sourceService.SlurpPullRequestComments(ctx, source.Owner, source.Name, pr.GetNumber())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch review comments of #%d from source repository: %w", pr.GetNumber(), err)
+	}
+
+	reqs := []*timedRequest{}
+	for _, op := range domain.NewReviewOpsList(reviews, domain.NewReviewThreads(reviewComments), targetComments) {
+		req := &createIssueCommentRequest{
+			Owner:             target.Owner,
+			Repo:              target.Name,
+			IssueNumber:       targetNumber,
+			SourceIssueNumber: pr.GetNumber(),
+		}
+		switch {
+		case op.Review != nil:
+			req.origin = origin{Source: reviewRef(op.Review)}
+			req.Body, err = domain.RenderReview(op.Review, u.commentHeader, u.rewriteBody)
+		case len(op.Replies) > 0:
+			// the mapping keeps the comment migrated from the thread first
+			req.Body, err = op.Thread.RenderReplies(u.commentHeader, u.rewriteBody, op.Replies)
+		default:
+			req.origin = origin{Source: reviewThreadRef(op.Thread)}
+			req.Body, err = op.Thread.Render(u.commentHeader, u.rewriteBody)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to build comment from review %s on #%d: %w", op, pr.GetNumber(), err)
+		}
+		reqs = append(reqs, &timedRequest{at: op.CreatedAt(), request: req})
+	}
+	return reqs, nil
+}
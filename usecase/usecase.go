@@ -2,82 +2,375 @@ package usecase
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/aereal/migrate-gh-repo/config"
 	"github.com/aereal/migrate-gh-repo/domain"
 	"github.com/aereal/migrate-gh-repo/external"
+	"github.com/aereal/migrate-gh-repo/journal"
+	"github.com/aereal/migrate-gh-repo/logging"
+	"github.com/aereal/migrate-gh-repo/mapping"
 	"github.com/google/go-github/github"
 )
 
-func New(userResolver *domain.UserAliasResolver, sourceClient, targetClient *github.Client, skipUsers []string) (*Usecase, error) {
+type Option func(u *Usecase)
+
+func WithJournal(j *journal.Journal) Option {
+	return func(u *Usecase) {
+		u.journal = j
+	}
+}
+
+func WithRetryPolicy(p *external.RetryPolicy) Option {
+	return func(u *Usecase) {
+		u.retry = p
+	}
+}
+
+func WithContinueOnError(continueOnError bool) Option {
+	return func(u *Usecase) {
+		u.continueOnError = continueOnError
+	}
+}
+
+// WithCopiedIssueBody makes migrated issues have the original body prefixed with the header.
+func WithCopiedIssueBody(header *domain.AttributionTemplate) Option {
+	return func(u *Usecase) {
+		u.issueBodyHeader = header
+	}
+}
+
+// WithIssueComments makes comments on source issues migrated with the header.
+func WithIssueComments(header *domain.AttributionTemplate) Option {
+	return func(u *Usecase) {
+		u.commentHeader = header
+	}
+}
+
+// WithPullRequestReviews makes reviews and review comments on source pull requests migrated along with comments.
+func WithPullRequestReviews(migrate bool) Option {
+	return func(u *Usecase) {
+		u.migrateReviews = migrate
+	}
+}
+
+// WithIssueNumberPreservation makes issues created with the same numbers as the source, filling gaps with placeholder issues.
+func WithIssueNumberPreservation(preserve bool) Option {
+	return func(u *Usecase) {
+		u.preserveIssueNumbers = preserve
+	}
+}
+
+// WithMappingStore makes source-to-target identities of migrated entities recorded in the store.
+func WithMappingStore(store *mapping.Store) Option {
+	return func(u *Usecase) {
+		u.mappings = store
+	}
+}
+
+// WithWebURLs tells where web pages of the source and the target are; references in migrated bodies are rewritten with them.
+func WithWebURLs(source, target string) Option {
+	return func(u *Usecase) {
+		u.sourceWebURL = source
+		u.targetWebURL = target
+	}
+}
+
+// WithMentionNeutralization makes @-mentions in copied bodies and comments wrapped in backticks not to notify users.
+func WithMentionNeutralization(neutralize bool) Option {
+	return func(u *Usecase) {
+		u.neutralizeMentions = neutralize
+	}
+}
+
+// WithPullRequestRecreation makes open pull requests created as pull requests if the branches exist on the target.
+func WithPullRequestRecreation(recreate bool) Option {
+	return func(u *Usecase) {
+		u.recreatePullRequests = recreate
+	}
+}
+
+// WithReleases makes releases and their assets migrated.
+func WithReleases(migrate bool) Option {
+	return func(u *Usecase) {
+		u.migrateReleases = migrate
+	}
+}
+
+// WithLabelConflictPolicy tells whether colors and descriptions of labels on the target are overwritten with the source ones.
+func WithLabelConflictPolicy(policy domain.ConflictPolicy) Option {
+	return func(u *Usecase) {
+		u.labelConflictPolicy = policy
+	}
+}
+
+// WithLabelMapping makes source labels renamed or merged into target ones on creating labels and issues.
+func WithLabelMapping(mapper *domain.LabelMapper) Option {
+	return func(u *Usecase) {
+		u.labelMapper = mapper
+	}
+}
+
+// WithPruning makes labels and milestones only on the target deleted.
+func WithPruning(labels, milestones bool) Option {
+	return func(u *Usecase) {
+		u.pruneLabels = labels
+		u.pruneMilestones = milestones
+	}
+}
+
+// WithSourceDownloadClient makes files redirected from the source API (e.g. release assets) fetched with the client, which must not send credentials of the API.
+func WithSourceDownloadClient(c *http.Client) Option {
+	return func(u *Usecase) {
+		u.sourceDownloadClient = c
+	}
+}
+
+func New(userResolver *domain.UserAliasResolver, sourceClient, targetClient *github.Client, skipUsers []string, opts ...Option) (*Usecase, error) {
 	if sourceClient == nil || targetClient == nil {
 		return nil, fmt.Errorf("both of sourceClient and targetClient must be given")
 	}
-	sourceService, err := external.NewGitHubService(sourceClient)
+	u := &Usecase{
+		sourceClient:        sourceClient,
+		targetClient:        targetClient,
+		userAliasResolver:   userResolver,
+		skipUsers:           skipUsers,
+		mappings:            mapping.NewMemoryStore(),
+		labelConflictPolicy: domain.SourceWins,
+		labelMapper:         domain.NewLabelMapper(nil),
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	u.mentions = domain.NewMentionRewriter(userResolver, u.neutralizeMentions)
+
+	var err error
+	u.sourceService, err = external.NewGitHubService(sourceClient, u.retry)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build GitHubService for source: %w", err)
 	}
-	targetService, err := external.NewGitHubService(targetClient)
+	u.targetService, err = external.NewGitHubService(targetClient, u.retry)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build GitHubService for target: %w", err)
 	}
-
-	return &Usecase{
-		sourceClient:         sourceClient,
-		targetClient:         targetClient,
-		sourceService:        sourceService,
-		targetService:        targetService,
-		userAliasResolver:    userResolver,
-		skipUsers:            skipUsers,
-		issueNumberIDMapping: map[int]int64{},
-	}, nil
+	return u, nil
 }
 
 type Usecase struct {
 	sourceClient         *github.Client
+	sourceDownloadClient *http.Client
 	sourceService        *external.GitHubService
 	targetClient         *github.Client
 	targetService        *external.GitHubService
 	userAliasResolver    *domain.UserAliasResolver
 	skipUsers            []string
-	issueNumberIDMapping map[int]int64 // number -> id
+	journal              *journal.Journal
+	retry                *external.RetryPolicy
+	continueOnError      bool
+	issueBodyHeader      *domain.AttributionTemplate
+	commentHeader        *domain.AttributionTemplate
+	preserveIssueNumbers bool
+	mappings             *mapping.Store
+	sourceWebURL         string
+	targetWebURL         string
+	references           *domain.ReferenceRewriter
+	bodyReferences       *domain.ReferenceRewriter
+	neutralizeMentions   bool
+	mentions             *domain.MentionRewriter
+	recreatePullRequests bool
+	migrateReviews       bool
+	migrateReleases      bool
+	labelConflictPolicy  domain.ConflictPolicy
+	labelMapper          *domain.LabelMapper
+	pruneLabels          bool
+	pruneMilestones      bool
+	lastReport           *FailureReport
 }
 
 type request interface {
-	Do(ctx context.Context, ghClient *github.Client) error
+	Do(ctx context.Context, ghClient *github.Client) (*outcome, error)
+	describe() *PlanStep
+}
+
+// idempotent is implemented by requests safe to be sent again after failures with unknown results (e.g. 502 or timeouts).
+// Other requests such as creations are retried only if GitHub rejected them or they are recoverable, since retrying them may duplicate entities.
+type idempotent interface {
+	idempotent()
+}
+
+// recoverable is implemented by requests creating entities which can be found on the target after failures with unknown results.
+// They are retried only if the entity created by the failed attempt is missing.
+type recoverable interface {
+	// findCreated returns identities of the entity created by the preceding attempt, or nil if it is missing.
+	findCreated(ctx context.Context, ghClient *github.Client) (*outcome, error)
+}
+
+// outcome holds identities of the entity created or updated on the target repository.
+type outcome struct {
+	id         int64
+	number     int
+	url        string
+	targetType string // contentTypePullRequest if the issue was created as a pull request
 }
 
 func (u *Usecase) Migrate(ctx context.Context, source, target *config.Repository) error {
-	if source == nil || target == nil {
-		return fmt.Errorf("Both of from/to repository must be given")
+	plan, err := u.Plan(ctx, source, target)
+	if err != nil {
+		return err
 	}
+	return u.Apply(ctx, plan)
+}
 
-	reqs, err := u.buildRequests(ctx, source, target)
+func (u *Usecase) Apply(ctx context.Context, plan *Plan) error {
+	reqs := make([]request, len(plan.Steps))
+	for i, step := range plan.Steps {
+		r, err := step.request()
+		if err != nil {
+			return fmt.Errorf("invalid step #%d: %w", i+1, err)
+		}
+		reqs[i] = r
+	}
+	keys, err := journalKeys(plan.Steps, reqs)
 	if err != nil {
 		return err
 	}
-	interval := time.Second * 1
-	tried := 0
-	intervalCount := 10
-	for _, r := range reqs {
-		if err := r.Do(ctx, u.targetClient); err != nil {
+
+	references := u.newPendingReferenceResolver(plan)
+	report := &FailureReport{}
+	u.lastReport = report
+	for i, r := range reqs {
+		step := plan.Steps[i]
+		if u.journal != nil {
+			if entry, ok := u.journal.Lookup(keys[i]); ok {
+				logging.Debugf("skip step #%d (%s %s): already completed", i+1, step.Action, step.Target)
+				if err := u.restoreMapping(r, entry); err != nil {
+					return err
+				}
+				report.Total++
+				report.Skipped++
+				continue
+			}
+		}
+		out, err := u.do(ctx, r, references)
+		if err != nil {
+			if !u.continueOnError {
+				return fmt.Errorf("step #%d (%s %s) failed: %w", i+1, step.Action, step.Target, err)
+			}
+			logging.Errorf("step #%d (%s %s) failed: %v", i+1, step.Action, step.Target, err)
+			report.Total++
+			report.add(i+1, step, err)
+			continue
+		}
+		report.Total++
+		report.Succeeded++
+		if err := u.recordMapping(r, out); err != nil {
 			return err
 		}
-		tried++
-		if tried >= intervalCount {
-			time.Sleep(interval)
-			tried = 0
+		if u.journal != nil {
+			entry := &journal.Entry{
+				Key:          keys[i],
+				Action:       step.Action,
+				Target:       step.Target,
+				TargetID:     out.id,
+				TargetNumber: out.number,
+				TargetType:   out.targetType,
+				CompletedAt:  time.Now(),
+			}
+			if err := u.journal.Record(entry); err != nil {
+				return err
+			}
 		}
 	}
+	if len(report.Failures) > 0 {
+		return report
+	}
+	return nil
+}
+
+// LastReport returns counts of requests processed by the last Apply even if all of them succeeded, or nil before Apply,
+// e.g. to report failures of phases out of the plan such as the wiki.
+func (u *Usecase) LastReport() *FailureReport {
+	return u.lastReport
+}
+
+func (u *Usecase) do(ctx context.Context, r request, references *domain.ReferenceRewriter) (*outcome, error) {
+	if res, ok := r.(resolvable); ok {
+		if err := res.resolve(u.mappings); err != nil {
+			return nil, err
+		}
+	}
+	if rr, ok := r.(referring); ok {
+		rr.resolveReferences(references)
+	}
+	if sr, ok := r.(sourceReading); ok {
+		sr.setSourceClient(u.sourceClient, u.sourceDownloadClient)
+	}
+	retry := u.retry.DoOnce
+	rec, canRecover := r.(recoverable)
+	if _, ok := r.(idempotent); ok || canRecover {
+		retry = u.retry.Do
+	}
+	var out *outcome
+	attempted := false
+	err := retry(ctx, func() error {
+		var err error
+		if attempted && canRecover {
+			// the failed attempt may have created the entity
+			if out, err = rec.findCreated(ctx, u.targetClient); err != nil || out != nil {
+				return err
+			}
+		}
+		attempted = true
+		out, err = r.Do(ctx, u.targetClient)
+		return err
+	})
+	return out, err
+}
+
+// journalKeys identifies each step by its action and canonical payload.
+// Identical steps in a plan are told apart by the order of their appearance.
+func journalKeys(steps []*PlanStep, reqs []request) ([]string, error) {
+	keys := make([]string, len(reqs))
+	seen := map[string]int{}
+	for i, r := range reqs {
+		payload, err := json.Marshal(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode payload of step #%d: %w", i+1, err)
+		}
+		h := sha256.New()
+		h.Write([]byte(steps[i].Action))
+		h.Write([]byte{0})
+		h.Write(payload)
+		digest := hex.EncodeToString(h.Sum(nil))
+		keys[i] = fmt.Sprintf("%s-%d", digest, seen[digest])
+		seen[digest]++
+	}
+	return keys, nil
+}
+
+func (u *Usecase) Verify(ctx context.Context, source, target *config.Repository) error {
+	if source == nil || target == nil {
+		return fmt.Errorf("Both of from/to repository must be given")
+	}
+
+	if _, err := u.sourceService.GetRepository(ctx, source.Owner, source.Name); err != nil {
+		return fmt.Errorf("cannot access source repository (%s/%s): %w", source.Owner, source.Name, err)
+	}
+	if _, err := u.targetService.GetRepository(ctx, target.Owner, target.Name); err != nil {
+		return fmt.Errorf("cannot access target repository (%s/%s): %w", target.Owner, target.Name, err)
+	}
 	return nil
 }
 
 func (u *Usecase) buildRequests(ctx context.Context, source, target *config.Repository) ([]request, error) {
 	reqs := []request{}
 
-	milestoneReqs, err := u.buildMilestoneRequests(ctx, source, target)
+	milestoneReqs, milestoneClosingReqs, err := u.buildMilestoneRequests(ctx, source, target)
 	if err != nil {
 		return nil, err
 	}
@@ -95,11 +388,22 @@ func (u *Usecase) buildRequests(ctx context.Context, source, target *config.Repo
 	}
 	reqs = append(reqs, issueReqs...)
 
-	projectReqs, err := u.buildProjectRequests(ctx, source, target, u.issueNumberIDMapping)
+	projectReqs, err := u.buildProjectRequests(ctx, source, target)
 	if err != nil {
 		return nil, err
 	}
 	reqs = append(reqs, projectReqs...)
 
+	if u.migrateReleases {
+		releaseReqs, err := u.buildReleaseRequests(ctx, source, target)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, releaseReqs...)
+	}
+
+	// closed milestones are closed at last so that issues can be assigned to them
+	reqs = append(reqs, milestoneClosingReqs...)
+
 	return reqs, nil
 }
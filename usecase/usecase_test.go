@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aereal/migrate-gh-repo/domain"
+	"github.com/aereal/migrate-gh-repo/external"
+	"github.com/google/go-github/github"
+)
+
+// newTestUsecase returns the usecase sending requests to srv as both of the source and the target.
+func newTestUsecase(t *testing.T, srv *httptest.Server, opts ...Option) *Usecase {
+	t.Helper()
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.BaseURL = baseURL
+	u, err := New(domain.NewUserAliasResolver(nil), client, client, nil, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func newTestPlan(t *testing.T, reqs ...request) *Plan {
+	t.Helper()
+	plan := &Plan{Source: "aereal/src", Target: "aereal/dest"}
+	for _, r := range reqs {
+		step, err := newPlanStep(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+	return plan
+}
+
+func TestUsecase_Apply_retry(t *testing.T) {
+	marker := "<!-- migrate-gh-repo:issue=MDU6SXNzdWUx -->"
+	tests := []struct {
+		name      string
+		req       request
+		listed    string
+		wantErr   bool
+		wantCalls string
+	}{
+		{
+			name:      "creation found after failure is not sent again",
+			req:       &createIssueRequest{Owner: "aereal", Repo: "dest", IssueReq: &github.IssueRequest{Title: strRef("bug"), Body: strRef("body\n\n" + marker)}},
+			listed:    `[{"id":30,"number":3,"body":"body\n\n` + marker + `"}]`,
+			wantCalls: "POST,GET",
+		},
+		{
+			name:      "creation missing after failure is sent again",
+			req:       &createIssueRequest{Owner: "aereal", Repo: "dest", IssueReq: &github.IssueRequest{Title: strRef("bug"), Body: strRef("body\n\n" + marker)}},
+			listed:    `[{"id":20,"number":2,"body":"other"}]`,
+			wantCalls: "POST,GET,POST",
+		},
+		{
+			name:      "creation not found by markers is not retried since it may have been committed",
+			req:       &createMilestoneRequest{Owner: "aereal", Repo: "dest", Milestone: &github.Milestone{Title: strRef("v1")}},
+			wantErr:   true,
+			wantCalls: "POST",
+		},
+		{
+			name:      "update is retried",
+			req:       &updateLabelRequest{Owner: "aereal", Repo: "dest", Name: "bug", Label: &github.Label{Name: strRef("bug")}},
+			wantCalls: "PATCH,PATCH",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := []string{}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, r.Method)
+				switch {
+				case len(calls) == 1:
+					w.WriteHeader(http.StatusBadGateway)
+				case r.Method == http.MethodGet:
+					w.Write([]byte(tt.listed))
+				default:
+					w.Write([]byte(`{"id":30,"number":3,"name":"bug"}`))
+				}
+			}))
+			defer srv.Close()
+			u := newTestUsecase(t, srv, WithRetryPolicy(external.NewRetryPolicy(3, time.Millisecond, time.Millisecond)))
+
+			err := u.Apply(context.Background(), newTestPlan(t, tt.req))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := strings.Join(calls, ","); got != tt.wantCalls {
+				t.Errorf("calls = %s, want %s", got, tt.wantCalls)
+			}
+		})
+	}
+}
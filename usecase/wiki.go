@@ -0,0 +1,22 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aereal/migrate-gh-repo/config"
+)
+
+// WikiLinkRewriter returns the function to rewrite links to the source repository in wiki pages
+// with identities of issues migrated so far.
+func (u *Usecase) WikiLinkRewriter(ctx context.Context, source, target *config.Repository) (func(page string) string, error) {
+	if u.references == nil {
+		// the plan was made by another process
+		sourceIssues, err := u.sourceService.SlurpIssues(ctx, source.Owner, source.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch issues from source repository: %w", err)
+		}
+		u.references = u.newReferenceRewriter(source, target, issueNumbers(sourceIssues))
+	}
+	return u.references.RewriteLinks, nil
+}